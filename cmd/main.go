@@ -73,6 +73,7 @@ func main() {
 	}
 	log.SetOutput(os.Stdout)
 	log.Printf("Spilo operator %s\n", version)
+	config.OperatorVersion = version
 
 	sigs := make(chan os.Signal, 1)
 	stop := make(chan struct{})
@@ -185,6 +185,83 @@ var PostgresCRDResourceValidation = apiextv1.CustomResourceValidation{
 							},
 						},
 					},
+					"checkJobs": {
+						Type:     "array",
+						Nullable: true,
+						Items: &apiextv1.JSONSchemaPropsOrArray{
+							Schema: &apiextv1.JSONSchemaProps{
+								Type:     "object",
+								Required: []string{"name", "schedule", "user"},
+								Properties: map[string]apiextv1.JSONSchemaProps{
+									"command": {
+										Type: "array",
+										Items: &apiextv1.JSONSchemaPropsOrArray{
+											Schema: &apiextv1.JSONSchemaProps{
+												Type: "string",
+											},
+										},
+									},
+									"database": {
+										Type: "string",
+									},
+									"env": {
+										Type: "array",
+										Items: &apiextv1.JSONSchemaPropsOrArray{
+											Schema: &apiextv1.JSONSchemaProps{
+												Type:                   "object",
+												XPreserveUnknownFields: util.True(),
+											},
+										},
+									},
+									"image": {
+										Type: "string",
+									},
+									"name": {
+										Type:    "string",
+										Pattern: "^[a-z0-9]([-a-z0-9]*[a-z0-9])?$",
+									},
+									"resources": {
+										Type: "object",
+										Properties: map[string]apiextv1.JSONSchemaProps{
+											"limits": {
+												Type: "object",
+												Properties: map[string]apiextv1.JSONSchemaProps{
+													"cpu": {
+														Type:    "string",
+														Pattern: "^(\\d+m|\\d+(\\.\\d{1,3})?)$",
+													},
+													"memory": {
+														Type:    "string",
+														Pattern: "^(\\d+(e\\d+)?|\\d+(\\.\\d+)?(e\\d+)?[EPTGMK]i?)$",
+													},
+												},
+											},
+											"requests": {
+												Type: "object",
+												Properties: map[string]apiextv1.JSONSchemaProps{
+													"cpu": {
+														Type:    "string",
+														Pattern: "^(\\d+m|\\d+(\\.\\d{1,3})?)$",
+													},
+													"memory": {
+														Type:    "string",
+														Pattern: "^(\\d+(e\\d+)?|\\d+(\\.\\d+)?(e\\d+)?[EPTGMK]i?)$",
+													},
+												},
+											},
+										},
+									},
+									"schedule": {
+										Type:    "string",
+										Pattern: "^(\\d+|\\*)(/\\d+)?(\\s+(\\d+|\\*)(/\\d+)?){4}$",
+									},
+									"user": {
+										Type: "string",
+									},
+								},
+							},
+						},
+					},
 					"clone": {
 						Type:     "object",
 						Required: []string{"cluster"},
@@ -1179,6 +1256,23 @@ var OperatorConfigCRDResourceValidation = apiextv1.CustomResourceValidation{
 					"kubernetes_use_configmaps": {
 						Type: "boolean",
 					},
+					"managed_by_fencing": {
+						Type: "string",
+						Enum: []apiextv1.JSON{
+							{
+								Raw: []byte(`"off"`),
+							},
+							{
+								Raw: []byte(`"warn"`),
+							},
+							{
+								Raw: []byte(`"refuse"`),
+							},
+						},
+					},
+					"managed_by_lease_duration": {
+						Type: "string",
+					},
 					"max_instances": {
 						Type:        "integer",
 						Description: "-1 = disabled",
@@ -1216,6 +1310,20 @@ var OperatorConfigCRDResourceValidation = apiextv1.CustomResourceValidation{
 							},
 						},
 					},
+					"upgrade_dry_run": {
+						Type: "string",
+						Enum: []apiextv1.JSON{
+							{
+								Raw: []byte(`"off"`),
+							},
+							{
+								Raw: []byte(`"report"`),
+							},
+							{
+								Raw: []byte(`"hold"`),
+							},
+						},
+					},
 					"workers": {
 						Type:    "integer",
 						Minimum: &min1,
@@ -1282,6 +1390,12 @@ var OperatorConfigCRDResourceValidation = apiextv1.CustomResourceValidation{
 									},
 								},
 							},
+							"check_job_docker_image": {
+								Type: "string",
+							},
+							"check_job_prefix": {
+								Type: "string",
+							},
 							"cluster_domain": {
 								Type: "string",
 							},
@@ -1304,6 +1418,15 @@ var OperatorConfigCRDResourceValidation = apiextv1.CustomResourceValidation{
 									},
 								},
 							},
+							"debug_container_image": {
+								Type: "string",
+							},
+							"debug_container_privileged": {
+								Type: "boolean",
+							},
+							"debug_container_ttl": {
+								Type: "string",
+							},
 							"delete_annotation_date_key": {
 								Type: "string",
 							},
@@ -1318,12 +1441,24 @@ var OperatorConfigCRDResourceValidation = apiextv1.CustomResourceValidation{
 									},
 								},
 							},
+							"enable_check_jobs": {
+								Type: "boolean",
+							},
 							"enable_cross_namespace_secret": {
 								Type: "boolean",
 							},
+							"enable_debug_containers": {
+								Type: "boolean",
+							},
+							"enable_effective_spec_annotation": {
+								Type: "boolean",
+							},
 							"enable_finalizers": {
 								Type: "boolean",
 							},
+							"enable_generation_annotations": {
+								Type: "boolean",
+							},
 							"enable_init_containers": {
 								Type: "boolean",
 							},
@@ -1339,6 +1474,9 @@ var OperatorConfigCRDResourceValidation = apiextv1.CustomResourceValidation{
 							"enable_pod_disruption_budget": {
 								Type: "boolean",
 							},
+							"enable_prometheus_rules": {
+								Type: "boolean",
+							},
 							"enable_readiness_probe": {
 								Type: "boolean",
 							},
@@ -1510,6 +1648,14 @@ var OperatorConfigCRDResourceValidation = apiextv1.CustomResourceValidation{
 							"pod_terminate_grace_period": {
 								Type: "string",
 							},
+							"prometheus_rule_labels": {
+								Type: "object",
+								AdditionalProperties: &apiextv1.JSONSchemaPropsOrBool{
+									Schema: &apiextv1.JSONSchemaProps{
+										Type: "string",
+									},
+								},
+							},
 							"secret_name_template": {
 								Type: "string",
 							},
@@ -1698,6 +1844,20 @@ var OperatorConfigCRDResourceValidation = apiextv1.CustomResourceValidation{
 							"aws_region": {
 								Type: "string",
 							},
+							"crash_artifact_core_dumps": {
+								Type: "boolean",
+							},
+							"crash_artifact_exit_codes": {
+								Type: "array",
+								Items: &apiextv1.JSONSchemaPropsOrArray{
+									Schema: &apiextv1.JSONSchemaProps{
+										Type: "integer",
+									},
+								},
+							},
+							"enable_crash_artifact_collection": {
+								Type: "boolean",
+							},
 							"enable_ebs_gp3_migration": {
 								Type: "boolean",
 							},
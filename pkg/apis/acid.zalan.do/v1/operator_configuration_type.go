@@ -75,6 +75,17 @@ type KubernetesMetaConfiguration struct {
 	EnableInitContainers                   *bool                        `json:"enable_init_containers,omitempty"`
 	EnableSidecars                         *bool                        `json:"enable_sidecars,omitempty"`
 	SharePgSocketWithSidecars              *bool                        `json:"share_pgsocket_with_sidecars,omitempty"`
+	EnableDebugContainers                  bool                         `json:"enable_debug_containers,omitempty"`
+	DebugContainerImage                    string                       `json:"debug_container_image,omitempty"`
+	DebugContainerPrivileged               bool                         `json:"debug_container_privileged,omitempty"`
+	DebugContainerTTL                      Duration                     `json:"debug_container_ttl,omitempty"`
+	EnablePrometheusRules                  bool                         `json:"enable_prometheus_rules,omitempty"`
+	PrometheusRuleLabels                   map[string]string            `json:"prometheus_rule_labels,omitempty"`
+	EnableEffectiveSpecAnnotation          bool                         `json:"enable_effective_spec_annotation,omitempty"`
+	EnableGenerationAnnotations            bool                         `json:"enable_generation_annotations,omitempty"`
+	EnableCheckJobs                        bool                         `json:"enable_check_jobs,omitempty"`
+	CheckJobPrefix                         string                       `json:"check_job_prefix,omitempty"`
+	CheckJobDockerImage                    config.StringTemplate        `json:"check_job_docker_image,omitempty"`
 	SecretNameTemplate                     config.StringTemplate        `json:"secret_name_template,omitempty"`
 	ClusterDomain                          string                       `json:"cluster_domain,omitempty"`
 	OAuthTokenSecretName                   spec.NamespacedName          `json:"oauth_token_secret_name,omitempty"`
@@ -163,6 +174,10 @@ type AWSGCPConfiguration struct {
 	AdditionalSecretMountPath    string `json:"additional_secret_mount_path,omitempty"`
 	EnableEBSGp3Migration        bool   `json:"enable_ebs_gp3_migration" default:"false"`
 	EnableEBSGp3MigrationMaxSize int64  `json:"enable_ebs_gp3_migration_max_size" default:"1000"`
+
+	EnableCrashArtifactCollection bool    `json:"enable_crash_artifact_collection,omitempty"`
+	CrashArtifactExitCodes        []int32 `json:"crash_artifact_exit_codes,omitempty"`
+	CrashArtifactCoreDumps        bool    `json:"crash_artifact_core_dumps,omitempty"`
 }
 
 // OperatorDebugConfiguration defines options for the debug mode
@@ -266,6 +281,9 @@ type OperatorConfigurationData struct {
 	Workers                       uint32                             `json:"workers,omitempty"`
 	ResyncPeriod                  Duration                           `json:"resync_period,omitempty"`
 	RepairPeriod                  Duration                           `json:"repair_period,omitempty"`
+	ManagedByFencing              string                             `json:"managed_by_fencing,omitempty"`
+	ManagedByLeaseDuration        Duration                           `json:"managed_by_lease_duration,omitempty"`
+	UpgradeDryRun                 string                             `json:"upgrade_dry_run,omitempty"`
 	SetMemoryRequestToLimit       bool                               `json:"set_memory_request_to_limit,omitempty"`
 	ShmVolume                     *bool                              `json:"enable_shm_volume,omitempty"`
 	SidecarImages                 map[string]string                  `json:"sidecar_docker_images,omitempty"` // deprecated in favour of SidecarContainers
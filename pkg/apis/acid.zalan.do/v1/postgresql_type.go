@@ -78,6 +78,7 @@ type PostgresSpec struct {
 	EnableLogicalBackup    bool                        `json:"enableLogicalBackup,omitempty"`
 	LogicalBackupRetention string                      `json:"logicalBackupRetention,omitempty"`
 	LogicalBackupSchedule  string                      `json:"logicalBackupSchedule,omitempty"`
+	CheckJobs              []CheckJob                  `json:"checkJobs,omitempty"`
 	StandbyCluster         *StandbyDescription         `json:"standby,omitempty"`
 	PodAnnotations         map[string]string           `json:"podAnnotations,omitempty"`
 	ServiceAnnotations     map[string]string           `json:"serviceAnnotations,omitempty"`
@@ -223,6 +224,18 @@ type Sidecar struct {
 	Command     []string           `json:"command,omitempty"`
 }
 
+// CheckJob defines a cron job running custom checks against the cluster's database
+type CheckJob struct {
+	*Resources  `json:"resources,omitempty"`
+	Name        string      `json:"name"`
+	DockerImage string      `json:"image,omitempty"`
+	Schedule    string      `json:"schedule"`
+	Command     []string    `json:"command,omitempty"`
+	User        string      `json:"user"`
+	Database    string      `json:"database,omitempty"`
+	Env         []v1.EnvVar `json:"env,omitempty"`
+}
+
 // UserFlags defines flags (such as superuser, nologin) that could be assigned to individual users
 type UserFlags []string
 
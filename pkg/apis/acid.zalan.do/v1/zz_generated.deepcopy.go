@@ -37,6 +37,11 @@ import (
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AWSGCPConfiguration) DeepCopyInto(out *AWSGCPConfiguration) {
 	*out = *in
+	if in.CrashArtifactExitCodes != nil {
+		in, out := &in.CrashArtifactExitCodes, &out.CrashArtifactExitCodes
+		*out = make([]int32, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -77,6 +82,39 @@ func (in *AdditionalVolume) DeepCopy() *AdditionalVolume {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CheckJob) DeepCopyInto(out *CheckJob) {
+	*out = *in
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(Resources)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]corev1.EnvVar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CheckJob.
+func (in *CheckJob) DeepCopy() *CheckJob {
+	if in == nil {
+		return nil
+	}
+	out := new(CheckJob)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CloneDescription) DeepCopyInto(out *CloneDescription) {
 	*out = *in
@@ -213,6 +251,13 @@ func (in *KubernetesMetaConfiguration) DeepCopyInto(out *KubernetesMetaConfigura
 		*out = new(bool)
 		**out = **in
 	}
+	if in.PrometheusRuleLabels != nil {
+		in, out := &in.PrometheusRuleLabels, &out.PrometheusRuleLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	out.OAuthTokenSecretName = in.OAuthTokenSecretName
 	out.InfrastructureRolesSecretName = in.InfrastructureRolesSecretName
 	if in.InfrastructureRolesDefs != nil {
@@ -458,7 +503,7 @@ func (in *OperatorConfigurationData) DeepCopyInto(out *OperatorConfigurationData
 	out.PostgresPodResources = in.PostgresPodResources
 	out.Timeouts = in.Timeouts
 	in.LoadBalancer.DeepCopyInto(&out.LoadBalancer)
-	out.AWSGCP = in.AWSGCP
+	in.AWSGCP.DeepCopyInto(&out.AWSGCP)
 	out.OperatorDebug = in.OperatorDebug
 	in.TeamsAPI.DeepCopyInto(&out.TeamsAPI)
 	out.LoggingRESTAPI = in.LoggingRESTAPI
@@ -815,6 +860,13 @@ func (in *PostgresSpec) DeepCopyInto(out *PostgresSpec) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.CheckJobs != nil {
+		in, out := &in.CheckJobs, &out.CheckJobs
+		*out = make([]CheckJob, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.StandbyCluster != nil {
 		in, out := &in.StandbyCluster, &out.StandbyCluster
 		*out = new(StandbyDescription)
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/sirupsen/logrus"
+	acidv1 "github.com/zalando/postgres-operator/pkg/apis/acid.zalan.do/v1"
 	"github.com/zalando/postgres-operator/pkg/cluster"
 	"github.com/zalando/postgres-operator/pkg/spec"
 	"github.com/zalando/postgres-operator/pkg/util"
@@ -33,11 +34,13 @@ type controllerInformer interface {
 	ClusterStatus(namespace, cluster string) (*cluster.ClusterStatus, error)
 	ClusterLogs(namespace, cluster string) ([]*spec.LogEntry, error)
 	ClusterHistory(namespace, cluster string) ([]*spec.Diff, error)
+	ClusterEffectiveSpec(namespace, cluster string) (*acidv1.PostgresSpec, error)
 	ClusterDatabasesMap() map[string][]string
 	WorkerLogs(workerID uint32) ([]*spec.LogEntry, error)
 	ListQueue(workerID uint32) (*spec.QueueDump, error)
 	GetWorkersCnt() uint32
 	WorkerStatus(workerID uint32) (*cluster.WorkerStatus, error)
+	UpgradeDryRun() (*spec.UpgradeDryRun, error)
 }
 
 // Server describes HTTP API server
@@ -54,21 +57,23 @@ const (
 )
 
 var (
-	clusterStatusRe  = fmt.Sprintf(`^/clusters/%s/%s/?$`, namespaceRe, clusterRe)
-	clusterLogsRe    = fmt.Sprintf(`^/clusters/%s/%s/logs/?$`, namespaceRe, clusterRe)
-	clusterHistoryRe = fmt.Sprintf(`^/clusters/%s/%s/history/?$`, namespaceRe, clusterRe)
-	teamURLRe        = fmt.Sprintf(`^/clusters/%s/?$`, teamRe)
-
-	clusterStatusURL     = regexp.MustCompile(clusterStatusRe)
-	clusterLogsURL       = regexp.MustCompile(clusterLogsRe)
-	clusterHistoryURL    = regexp.MustCompile(clusterHistoryRe)
-	teamURL              = regexp.MustCompile(teamURLRe)
-	workerLogsURL        = regexp.MustCompile(`^/workers/(?P<id>\d+)/logs/?$`)
-	workerEventsQueueURL = regexp.MustCompile(`^/workers/(?P<id>\d+)/queue/?$`)
-	workerStatusURL      = regexp.MustCompile(`^/workers/(?P<id>\d+)/status/?$`)
-	workerAllQueue       = regexp.MustCompile(`^/workers/all/queue/?$`)
-	workerAllStatus      = regexp.MustCompile(`^/workers/all/status/?$`)
-	clustersURL          = "/clusters/"
+	clusterStatusRe        = fmt.Sprintf(`^/clusters/%s/%s/?$`, namespaceRe, clusterRe)
+	clusterLogsRe          = fmt.Sprintf(`^/clusters/%s/%s/logs/?$`, namespaceRe, clusterRe)
+	clusterHistoryRe       = fmt.Sprintf(`^/clusters/%s/%s/history/?$`, namespaceRe, clusterRe)
+	clusterEffectiveSpecRe = fmt.Sprintf(`^/clusters/%s/%s/effective_spec/?$`, namespaceRe, clusterRe)
+	teamURLRe              = fmt.Sprintf(`^/clusters/%s/?$`, teamRe)
+
+	clusterStatusURL        = regexp.MustCompile(clusterStatusRe)
+	clusterLogsURL          = regexp.MustCompile(clusterLogsRe)
+	clusterHistoryURL       = regexp.MustCompile(clusterHistoryRe)
+	clusterEffectiveSpecURL = regexp.MustCompile(clusterEffectiveSpecRe)
+	teamURL                 = regexp.MustCompile(teamURLRe)
+	workerLogsURL           = regexp.MustCompile(`^/workers/(?P<id>\d+)/logs/?$`)
+	workerEventsQueueURL    = regexp.MustCompile(`^/workers/(?P<id>\d+)/queue/?$`)
+	workerStatusURL         = regexp.MustCompile(`^/workers/(?P<id>\d+)/status/?$`)
+	workerAllQueue          = regexp.MustCompile(`^/workers/all/queue/?$`)
+	workerAllStatus         = regexp.MustCompile(`^/workers/all/status/?$`)
+	clustersURL             = "/clusters/"
 )
 
 // New creates new HTTP API server
@@ -92,6 +97,8 @@ func New(controller controllerInformer, port int, logger *logrus.Logger) *Server
 	mux.HandleFunc("/clusters/", s.clusters)
 	mux.HandleFunc("/workers/", s.workers)
 	mux.HandleFunc("/databases/", s.databases)
+	mux.HandleFunc("/upgrade_dry_run/", s.upgradeDryRun)
+	mux.HandleFunc("/metrics", s.metrics)
 
 	s.http = http.Server{
 		Addr:        fmt.Sprintf(":%d", port),
@@ -195,6 +202,9 @@ func (s *Server) clusters(w http.ResponseWriter, req *http.Request) {
 	} else if matches := util.FindNamedStringSubmatch(clusterHistoryURL, req.URL.Path); matches != nil {
 		namespace := matches["namespace"]
 		resp, err = s.controller.ClusterHistory(namespace, matches["cluster"])
+	} else if matches := util.FindNamedStringSubmatch(clusterEffectiveSpecURL, req.URL.Path); matches != nil {
+		namespace := matches["namespace"]
+		resp, err = s.controller.ClusterEffectiveSpec(namespace, matches["cluster"])
 	} else if req.URL.Path == clustersURL {
 		clusterNamesPerTeam := make(map[string][]string)
 		for team, clusters := range s.controller.TeamClusterList() {
@@ -262,6 +272,21 @@ func (s *Server) databases(w http.ResponseWriter, req *http.Request) {
 	s.respond(databaseNamesPerCluster, nil, w)
 }
 
+func (s *Server) upgradeDryRun(w http.ResponseWriter, req *http.Request) {
+	report, err := s.controller.UpgradeDryRun()
+	s.respond(report, err, w)
+}
+
+func (s *Server) metrics(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+	// the report is nil as long as no dry run has been run
+	report, _ := s.controller.UpgradeDryRun()
+	if err := writeUpgradeDryRunMetrics(w, report); err != nil {
+		s.logger.Errorf("could not write metrics: %v", err)
+	}
+}
+
 func (s *Server) allQueues(w http.ResponseWriter, r *http.Request) {
 	workersCnt := s.controller.GetWorkersCnt()
 	resp := make(map[uint32]*spec.QueueDump, workersCnt)
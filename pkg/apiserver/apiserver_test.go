@@ -1,13 +1,19 @@
 package apiserver
 
 import (
+	"bytes"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/zalando/postgres-operator/pkg/spec"
 )
 
 const (
 	clusterStatusTest        = "/clusters/test-namespace/testcluster/"
 	clusterStatusNumericTest = "/clusters/test-namespace-1/testcluster/"
 	clusterLogsTest          = "/clusters/test-namespace/testcluster/logs/"
+	clusterEffectiveSpecTest = "/clusters/test-namespace/testcluster/effective_spec/"
 	teamTest                 = "/clusters/test-id/"
 )
 
@@ -24,7 +30,45 @@ func TestUrlRegexps(t *testing.T) {
 		t.Errorf("clusterLogsURL can't match %s", clusterLogsTest)
 	}
 
+	if clusterEffectiveSpecURL.FindStringSubmatch(clusterEffectiveSpecTest) == nil {
+		t.Errorf("clusterEffectiveSpecURL can't match %s", clusterEffectiveSpecTest)
+	}
+
 	if teamURL.FindStringSubmatch(teamTest) == nil {
 		t.Errorf("teamURL can't match %s", teamTest)
 	}
 }
+
+func TestUpgradeDryRunMetrics(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeUpgradeDryRunMetrics(&buf, nil); err != nil {
+		t.Fatalf("could not write metrics: %v", err)
+	}
+	if !strings.Contains(buf.String(), "postgres_operator_upgrade_dry_run_completed 0\n") {
+		t.Errorf("expected dry run not to be completed, got:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	report := &spec.UpgradeDryRun{
+		EndTime:       time.Unix(1700000000, 0),
+		Clusters:      3,
+		Changed:       2,
+		RollingUpdate: 1,
+		Reasons:       map[string]int{`new statefulset's container "postgres" (index 0) image does not match the current one`: 2},
+	}
+	if err := writeUpgradeDryRunMetrics(&buf, report); err != nil {
+		t.Fatalf("could not write metrics: %v", err)
+	}
+	for _, expected := range []string{
+		"postgres_operator_upgrade_dry_run_completed 1\n",
+		"postgres_operator_upgrade_dry_run_timestamp_seconds 1700000000\n",
+		"postgres_operator_upgrade_dry_run_clusters 3\n",
+		"postgres_operator_upgrade_dry_run_statefulsets_changed 2\n",
+		"postgres_operator_upgrade_dry_run_statefulsets_rolling_update 1\n",
+		`postgres_operator_upgrade_dry_run_reasons{reason="new statefulset's container \"postgres\" (index 0) image does not match the current one"} 2` + "\n",
+	} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("expected metrics to contain %q, got:\n%s", expected, buf.String())
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package apiserver
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/zalando/postgres-operator/pkg/spec"
+)
+
+const metricsPrefix = "postgres_operator_upgrade_dry_run"
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func writeGauge(w io.Writer, name, help string, values map[string]float64) error {
+	if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name); err != nil {
+		return err
+	}
+
+	labels := make([]string, 0, len(values))
+	for label := range values {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	for _, label := range labels {
+		if _, err := fmt.Fprintf(w, "%s%s %s\n", name, label, strconv.FormatFloat(values[label], 'f', -1, 64)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeUpgradeDryRunMetrics writes the outcome of the upgrade dry run in the Prometheus text format
+func writeUpgradeDryRunMetrics(w io.Writer, report *spec.UpgradeDryRun) error {
+	if report == nil {
+		return writeGauge(w, metricsPrefix+"_completed", "Whether an upgrade dry run has been run since the operator started.",
+			map[string]float64{"": 0})
+	}
+
+	reasons := make(map[string]float64, len(report.Reasons))
+	for reason, count := range report.Reasons {
+		reasons[fmt.Sprintf(`{reason="%s"}`, labelValueEscaper.Replace(reason))] = float64(count)
+	}
+
+	gauges := []struct {
+		name   string
+		help   string
+		values map[string]float64
+	}{
+		{"_completed", "Whether an upgrade dry run has been run since the operator started.",
+			map[string]float64{"": 1}},
+		{"_timestamp_seconds", "Time the upgrade dry run finished.",
+			map[string]float64{"": float64(report.EndTime.Unix())}},
+		{"_clusters", "Number of clusters covered by the upgrade dry run.",
+			map[string]float64{"": float64(report.Clusters)}},
+		{"_statefulsets_changed", "Number of statefulsets which would change.",
+			map[string]float64{"": float64(report.Changed)}},
+		{"_statefulsets_replaced", "Number of statefulsets which would be replaced.",
+			map[string]float64{"": float64(report.Replace)}},
+		{"_statefulsets_rolling_update", "Number of statefulsets whose pods would need a rolling update.",
+			map[string]float64{"": float64(report.RollingUpdate)}},
+		{"_failed", "Number of clusters whose statefulset could not be generated.",
+			map[string]float64{"": float64(report.Failed)}},
+		{"_reasons", "Number of statefulsets which would change for the given reason.",
+			reasons},
+	}
+	for _, gauge := range gauges {
+		if err := writeGauge(w, metricsPrefix+gauge.name, gauge.help, gauge.values); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,324 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+
+	acidv1 "github.com/zalando/postgres-operator/pkg/apis/acid.zalan.do/v1"
+	"github.com/zalando/postgres-operator/pkg/util"
+	"github.com/zalando/postgres-operator/pkg/util/config"
+	"github.com/zalando/postgres-operator/pkg/util/k8sutil"
+)
+
+const (
+	checkJobContainerName = "check"
+	checkJobNameLabel     = "check-job"
+	checkJobDatabase      = "postgres"
+)
+
+// checkJobLabels distinguish check job CronJobs from the logical backup job of the cluster
+func (c *Cluster) checkJobLabels() labels.Set {
+	return labels.Merge(c.labelsSet(false), labels.Set{"application": "spilo-check-job"})
+}
+
+// getCheckJobName returns the name of the CronJob of a check; the job itself may not exist
+func (c *Cluster) getCheckJobName(name string) string {
+	return trimCronjobName(fmt.Sprintf("%s%s-%s", c.OpConfig.CheckJobPrefix, c.Name, name))
+}
+
+// getCheckJobImage fills in the placeholders of the image given in the manifest or the configured default
+func (c *Cluster) getCheckJobImage(checkJob *acidv1.CheckJob) (string, error) {
+	image := c.OpConfig.CheckJobDockerImage
+	if checkJob.DockerImage != "" {
+		image = config.StringTemplate(checkJob.DockerImage)
+	}
+	if image == "" {
+		return "", fmt.Errorf("no image given and no check_job_docker_image configured")
+	}
+
+	return image.Format(
+		"name", checkJob.Name,
+		"cluster", c.Name,
+		"team", c.Spec.TeamID,
+		"pgversion", c.Spec.PostgresqlParam.PgVersion,
+	), nil
+}
+
+// checkJobUserAllowed reports whether the check job may run with the credentials of the given user.
+// Only users defined in the manifest are allowed, system users like the superuser never are.
+func (c *Cluster) checkJobUserAllowed(username string) bool {
+	for _, systemUser := range c.systemUsers {
+		if systemUser.Name == username {
+			return false
+		}
+	}
+	if _, defined := c.Spec.Users[username]; !defined {
+		return false
+	}
+	_, exists := c.pgUsers[username]
+	return exists
+}
+
+func (c *Cluster) generateCheckJobEnvVars(checkJob *acidv1.CheckJob, username string) []v1.EnvVar {
+	envVars := []v1.EnvVar{
+		{
+			Name:  "CLUSTER_NAME",
+			Value: c.Name,
+		},
+		{
+			Name:  "CHECK_NAME",
+			Value: checkJob.Name,
+		},
+		{
+			Name:  "PGHOST",
+			Value: c.serviceName(Master),
+		},
+		{
+			Name:  "PGPORT",
+			Value: fmt.Sprintf("%d", pgPort),
+		},
+		{
+			Name:  "PGUSER",
+			Value: username,
+		},
+		{
+			Name: "PGPASSWORD",
+			ValueFrom: &v1.EnvVarSource{
+				SecretKeyRef: &v1.SecretKeySelector{
+					LocalObjectReference: v1.LocalObjectReference{
+						Name: c.credentialSecretName(username),
+					},
+					Key: "password",
+				},
+			},
+		},
+		{
+			Name:  "PGDATABASE",
+			Value: util.Coalesce(checkJob.Database, checkJobDatabase),
+		},
+		{
+			Name:  "PGSSLMODE",
+			Value: "require",
+		},
+	}
+
+	return append(envVars, checkJob.Env...)
+}
+
+func (c *Cluster) generateCheckJob(checkJob *acidv1.CheckJob) (*batchv1.CronJob, error) {
+	image, err := c.getCheckJobImage(checkJob)
+	if err != nil {
+		return nil, err
+	}
+
+	username := checkJob.User
+	if username == "" {
+		return nil, fmt.Errorf("no user given to run the check with")
+	}
+	if !c.checkJobUserAllowed(username) {
+		return nil, fmt.Errorf("user %q is not defined in the manifest or is a system user", username)
+	}
+
+	resourceRequirements, err := c.generateResourceRequirements(
+		checkJob.Resources, makeDefaultResources(&c.OpConfig), checkJobContainerName)
+	if err != nil {
+		return nil, fmt.Errorf("could not generate resource requirements: %v", err)
+	}
+
+	container := v1.Container{
+		Name:            checkJobContainerName,
+		Image:           image,
+		ImagePullPolicy: v1.PullIfNotPresent,
+		Command:         checkJob.Command,
+		Env:             c.generateCheckJobEnvVars(checkJob, username),
+		Resources:       *resourceRequirements,
+	}
+
+	jobLabels := labels.Merge(c.labelsSet(true), c.checkJobLabels())
+	jobLabels[checkJobNameLabel] = checkJob.Name
+
+	podTemplate := v1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels:      jobLabels,
+			Annotations: c.annotationsSet(c.generatePodAnnotations(&c.Spec)),
+		},
+		Spec: v1.PodSpec{
+			Containers:    []v1.Container{container},
+			RestartPolicy: v1.RestartPolicyNever,
+			Tolerations:   tolerations(&c.Spec.Tolerations, c.OpConfig.PodToleration),
+		},
+	}
+
+	cronJob := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            c.getCheckJobName(checkJob.Name),
+			Namespace:       c.Namespace,
+			Labels:          jobLabels,
+			Annotations:     c.annotationsSet(nil),
+			OwnerReferences: c.ownerReferences(),
+		},
+		Spec: batchv1.CronJobSpec{
+			Schedule: checkJob.Schedule,
+			JobTemplate: batchv1.JobTemplateSpec{
+				Spec: batchv1.JobSpec{
+					Template: podTemplate,
+				},
+			},
+			ConcurrencyPolicy: batchv1.ForbidConcurrent,
+		},
+	}
+	cronJob.Annotations = c.withGenerationAnnotations(cronJob.Annotations, cronJob.Spec)
+
+	return cronJob, nil
+}
+
+func (c *Cluster) compareCheckJob(cur, new *batchv1.CronJob) (match bool, reasons []string) {
+	if cur.Spec.Schedule != new.Spec.Schedule {
+		reasons = append(reasons, fmt.Sprintf("new job's schedule %q does not match the current one %q", new.Spec.Schedule, cur.Spec.Schedule))
+	}
+
+	curContainers := cur.Spec.JobTemplate.Spec.Template.Spec.Containers
+	newContainers := new.Spec.JobTemplate.Spec.Template.Spec.Containers
+	if len(curContainers) != len(newContainers) {
+		return false, append(reasons, "new job's containers do not match the current ones")
+	}
+	for i := range curContainers {
+		if curContainers[i].Image != newContainers[i].Image {
+			reasons = append(reasons, fmt.Sprintf("new job's image %q does not match the current one %q", newContainers[i].Image, curContainers[i].Image))
+		}
+		if !reflect.DeepEqual(curContainers[i].Command, newContainers[i].Command) {
+			reasons = append(reasons, "new job's command does not match the current one")
+		}
+		if !compareEnv(curContainers[i].Env, newContainers[i].Env) {
+			reasons = append(reasons, "new job's environment does not match the current one")
+		}
+		if !compareResources(&curContainers[i].Resources, &newContainers[i].Resources) {
+			reasons = append(reasons, "new job's resources do not match the current ones")
+		}
+	}
+
+	if changed, reason := c.compareAnnotations(cur.Spec.JobTemplate.Spec.Template.Annotations, new.Spec.JobTemplate.Spec.Template.Annotations, nil); changed {
+		reasons = append(reasons, "new job's pod template metadata annotations do not match "+reason)
+	}
+
+	return len(reasons) == 0, reasons
+}
+
+func (c *Cluster) patchCheckJob(newJob *batchv1.CronJob) (*batchv1.CronJob, error) {
+	patchData, err := specWithGenerationPatch(newJob.Spec, newJob.Annotations)
+	if err != nil {
+		return nil, fmt.Errorf("could not form patch for check job %q: %v", newJob.Name, err)
+	}
+
+	cronJob, err := c.KubeClient.CronJobsGetter.CronJobs(c.Namespace).Patch(
+		context.TODO(), newJob.Name, types.MergePatchType, patchData, metav1.PatchOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("could not patch check job %q: %v", newJob.Name, err)
+	}
+
+	return cronJob, nil
+}
+
+// syncCheckJobs creates, updates and removes the CronJobs of the check jobs defined in the manifest.
+// A check which cannot be generated does not hold up the others and its current job is kept.
+func (c *Cluster) syncCheckJobs() error {
+	c.setProcessName("syncing check jobs")
+
+	desiredJobs := make(map[string]*batchv1.CronJob)
+	failedJobs := make(map[string]bool)
+	if c.OpConfig.EnableCheckJobs && c.getNumberOfInstances(&c.Spec) > 0 {
+		checkNames := make(map[string]string)
+		for i := range c.Spec.CheckJobs {
+			checkJob := &c.Spec.CheckJobs[i]
+			jobName := c.getCheckJobName(checkJob.Name)
+			if other, exists := checkNames[jobName]; exists {
+				c.logger.Errorf("check %q maps to the same job name %q as check %q, skipping it", checkJob.Name, jobName, other)
+				continue
+			}
+			checkNames[jobName] = checkJob.Name
+
+			desiredJob, err := c.generateCheckJob(checkJob)
+			if err != nil {
+				c.logger.Errorf("could not generate check job %q: %v", checkJob.Name, err)
+				failedJobs[jobName] = true
+				continue
+			}
+			desiredJobs[desiredJob.Name] = desiredJob
+		}
+	} else if !c.OpConfig.EnableCheckJobs && len(c.Spec.CheckJobs) > 0 {
+		c.logger.Warning("check jobs are defined in the manifest, but disabled in the operator configuration")
+	}
+
+	currentJobs, err := c.KubeClient.CronJobsGetter.CronJobs(c.Namespace).List(context.TODO(),
+		metav1.ListOptions{LabelSelector: c.checkJobLabels().String()})
+	if err != nil {
+		return fmt.Errorf("could not list check jobs: %v", err)
+	}
+
+	checkJobs := make(map[string]*batchv1.CronJob)
+	for i := range currentJobs.Items {
+		job := &currentJobs.Items[i]
+		if failedJobs[job.Name] {
+			checkJobs[job.Name] = job
+			continue
+		}
+		desiredJob, exists := desiredJobs[job.Name]
+		if !exists {
+			c.logger.Infof("removing check job %q", job.Name)
+			if err = c.KubeClient.CronJobsGetter.CronJobs(c.Namespace).Delete(context.TODO(), job.Name, c.deleteOptions); err != nil && !k8sutil.ResourceNotFound(err) {
+				return fmt.Errorf("could not delete check job %q: %v", job.Name, err)
+			}
+			continue
+		}
+		delete(desiredJobs, job.Name)
+
+		if match, reasons := c.compareCheckJob(job, desiredJob); !match {
+			c.logger.Infof("check job %q is not in the desired state and needs to be updated: %s", job.Name, strings.Join(reasons, ", "))
+			if job, err = c.patchCheckJob(desiredJob); err != nil {
+				return err
+			}
+		}
+		checkJobs[job.Name] = job
+	}
+
+	for name, desiredJob := range desiredJobs {
+		c.logger.Infof("creating check job %q", name)
+		job, err := c.KubeClient.CronJobsGetter.CronJobs(c.Namespace).Create(context.TODO(), desiredJob, metav1.CreateOptions{})
+		if err != nil {
+			return fmt.Errorf("could not create check job %q: %v", name, err)
+		}
+		checkJobs[name] = job
+	}
+	c.CheckJobs = checkJobs
+
+	return nil
+}
+
+func (c *Cluster) deleteCheckJobs() error {
+	jobs, err := c.KubeClient.CronJobsGetter.CronJobs(c.Namespace).List(context.TODO(),
+		metav1.ListOptions{LabelSelector: c.checkJobLabels().String()})
+	if err != nil {
+		return fmt.Errorf("could not list check jobs: %v", err)
+	}
+
+	for _, job := range jobs.Items {
+		c.logger.Infof("removing check job %q", job.Name)
+		err = c.KubeClient.CronJobsGetter.CronJobs(c.Namespace).Delete(context.TODO(), job.Name, c.deleteOptions)
+		if k8sutil.ResourceNotFound(err) {
+			c.logger.Debugf("check job %q has already been deleted", job.Name)
+		} else if err != nil {
+			return fmt.Errorf("could not delete check job %q: %v", job.Name, err)
+		}
+	}
+	c.CheckJobs = nil
+
+	return nil
+}
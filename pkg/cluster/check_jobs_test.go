@@ -0,0 +1,174 @@
+package cluster
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	acidv1 "github.com/zalando/postgres-operator/pkg/apis/acid.zalan.do/v1"
+	"github.com/zalando/postgres-operator/pkg/util/config"
+	"github.com/zalando/postgres-operator/pkg/util/k8sutil"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newCheckJobTestCluster(client k8sutil.KubernetesClient, checkJobs []acidv1.CheckJob) *Cluster {
+	cluster := New(
+		Config{
+			OpConfig: config.Config{
+				EnableCheckJobs:     true,
+				CheckJobPrefix:      "check-",
+				CheckJobDockerImage: "registry.example.com/checks/{name}:{pgversion}",
+				Auth: config.Auth{
+					SecretNameTemplate: "{username}.{cluster}.credentials.{tprkind}.{tprgroup}",
+					SuperUsername:      "postgres",
+				},
+				Resources: config.Resources{
+					ClusterLabels:        map[string]string{"application": "spilo"},
+					ClusterNameLabel:     "cluster-name",
+					DefaultCPURequest:    "100m",
+					DefaultMemoryRequest: "100Mi",
+					MinInstances:         -1,
+					MaxInstances:         -1,
+				},
+			},
+		}, client, acidv1.Postgresql{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "acid-test-cluster",
+				Namespace: "default",
+			},
+			Spec: acidv1.PostgresSpec{
+				TeamID:            "acid",
+				NumberOfInstances: 1,
+				PostgresqlParam:   acidv1.PostgresqlParam{PgVersion: "17"},
+				Users:             map[string]acidv1.UserFlags{"checker": {}},
+				CheckJobs:         checkJobs,
+			},
+		}, logger, eventRecorder)
+	cluster.initUsers()
+
+	return cluster
+}
+
+func TestGenerateCheckJob(t *testing.T) {
+	checkJob := acidv1.CheckJob{
+		Name:     "compliance",
+		Schedule: "0 * * * *",
+		Command:  []string{"/check.sh"},
+		User:     "checker",
+		Database: "app",
+		Env:      []v1.EnvVar{{Name: "THRESHOLD", Value: "10"}},
+	}
+	cluster := newCheckJobTestCluster(k8sutil.KubernetesClient{}, []acidv1.CheckJob{checkJob})
+
+	job, err := cluster.generateCheckJob(&checkJob)
+	assert.NoError(t, err)
+	assert.Equal(t, "check-acid-test-cluster-compliance", job.Name)
+	assert.Equal(t, "0 * * * *", job.Spec.Schedule)
+	assert.Equal(t, "spilo-check-job", job.Labels["application"])
+	assert.Equal(t, "compliance", job.Labels[checkJobNameLabel])
+
+	container := job.Spec.JobTemplate.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, "registry.example.com/checks/compliance:17", container.Image)
+	assert.Equal(t, []string{"/check.sh"}, container.Command)
+
+	env := make(map[string]v1.EnvVar)
+	for _, envVar := range container.Env {
+		env[envVar.Name] = envVar
+	}
+	assert.Equal(t, "acid-test-cluster", env["PGHOST"].Value)
+	assert.Equal(t, "checker", env["PGUSER"].Value)
+	assert.Equal(t, "app", env["PGDATABASE"].Value)
+	assert.Equal(t, "checker.acid-test-cluster.credentials.postgresql.acid.zalan.do", env["PGPASSWORD"].ValueFrom.SecretKeyRef.Name)
+	assert.Equal(t, "10", env["THRESHOLD"].Value)
+
+	// credentials can only be injected for users of the cluster
+	checkJob.User = "unknown"
+	_, err = cluster.generateCheckJob(&checkJob)
+	assert.Error(t, err)
+
+	// the user has to be given explicitly, there is no fallback to the superuser
+	checkJob.User = ""
+	_, err = cluster.generateCheckJob(&checkJob)
+	assert.Error(t, err)
+
+	// system users are never handed out to check jobs
+	checkJob.User = "postgres"
+	_, err = cluster.generateCheckJob(&checkJob)
+	assert.Error(t, err)
+
+	// without image in the manifest or the configuration there is nothing to run
+	checkJob.User = "checker"
+	cluster.OpConfig.CheckJobDockerImage = ""
+	_, err = cluster.generateCheckJob(&checkJob)
+	assert.Error(t, err)
+}
+
+func TestSyncCheckJobs(t *testing.T) {
+	clientSet := fake.NewSimpleClientset()
+	client := k8sutil.KubernetesClient{
+		CronJobsGetter: clientSet.BatchV1(),
+	}
+	cronJobs := clientSet.BatchV1().CronJobs("default")
+
+	cluster := newCheckJobTestCluster(client, []acidv1.CheckJob{
+		{Name: "compliance", Schedule: "0 * * * *", User: "checker"},
+		{Name: "quality", Schedule: "30 * * * *", DockerImage: "quality:latest", User: "checker"},
+	})
+
+	err := cluster.syncCheckJobs()
+	assert.NoError(t, err)
+	jobs, err := cronJobs.List(context.TODO(), metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, jobs.Items, 2)
+	assert.Len(t, cluster.CheckJobs, 2)
+
+	// changing the schedule updates the job
+	cluster.Spec.CheckJobs[0].Schedule = "15 * * * *"
+	err = cluster.syncCheckJobs()
+	assert.NoError(t, err)
+	job, err := cronJobs.Get(context.TODO(), "check-acid-test-cluster-compliance", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "15 * * * *", job.Spec.Schedule)
+
+	// a check which cannot be generated keeps its job and does not block the others
+	cluster.Spec.CheckJobs[1].User = "unknown"
+	cluster.Spec.CheckJobs = append(cluster.Spec.CheckJobs, acidv1.CheckJob{
+		Name: "availability", Schedule: "*/5 * * * *", User: "checker"})
+	err = cluster.syncCheckJobs()
+	assert.NoError(t, err)
+	jobs, err = cronJobs.List(context.TODO(), metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, jobs.Items, 3)
+	assert.Len(t, cluster.CheckJobs, 3)
+
+	// checks whose job names collide after truncation are skipped
+	longName := strings.Repeat("a", 40)
+	cluster.Spec.CheckJobs = append(cluster.Spec.CheckJobs[:1],
+		acidv1.CheckJob{Name: longName + "-first", Schedule: "0 * * * *", User: "checker"},
+		acidv1.CheckJob{Name: longName + "-second", Schedule: "30 * * * *", User: "checker"})
+	err = cluster.syncCheckJobs()
+	assert.NoError(t, err)
+	job, err = cronJobs.Get(context.TODO(), cluster.getCheckJobName(longName+"-first"), metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, longName+"-first", job.Labels[checkJobNameLabel])
+	cluster.Spec.CheckJobs = cluster.Spec.CheckJobs[:1]
+
+	// removing a check from the manifest deletes its job
+	cluster.Spec.CheckJobs = cluster.Spec.CheckJobs[:1]
+	err = cluster.syncCheckJobs()
+	assert.NoError(t, err)
+	jobs, err = cronJobs.List(context.TODO(), metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, jobs.Items, 1)
+
+	// disabling check jobs removes all of them
+	cluster.OpConfig.EnableCheckJobs = false
+	err = cluster.syncCheckJobs()
+	assert.NoError(t, err)
+	jobs, err = cronJobs.List(context.TODO(), metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Empty(t, jobs.Items)
+}
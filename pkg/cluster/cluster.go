@@ -33,6 +33,7 @@ import (
 	policyv1 "k8s.io/api/policy/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
@@ -56,6 +57,8 @@ type Config struct {
 	InfrastructureRoles          map[string]spec.PgUser // inherited from the controller
 	PodServiceAccount            *v1.ServiceAccount
 	PodServiceAccountRoleBinding *rbacv1.RoleBinding
+	OperatorVersion              string
+	OperatorIdentity             string
 }
 
 type kubeResources struct {
@@ -69,7 +72,9 @@ type kubeResources struct {
 	PrimaryPodDisruptionBudget    *policyv1.PodDisruptionBudget
 	CriticalOpPodDisruptionBudget *policyv1.PodDisruptionBudget
 	LogicalBackupJob              *batchv1.CronJob
+	CheckJobs                     map[string]*batchv1.CronJob
 	Streams                       map[string]*zalandov1.FabricEventStream
+	PrometheusRule                *unstructured.Unstructured
 	//Pods are treated separately
 }
 
@@ -103,6 +108,10 @@ type Cluster struct {
 	EBSVolumes          map[string]volumes.VolumeProperties
 	VolumeResizer       volumes.VolumeResizer
 	currentMajorVersion int
+	effectiveSpec       *acidv1.PostgresSpec // protected by specMu
+
+	crashArtifactsMu        sync.Mutex
+	crashArtifactsCollected map[string]time.Time // protected by crashArtifactsMu
 }
 
 type compareStatefulsetResult struct {
@@ -160,6 +169,8 @@ func New(cfg Config, kubeClient k8sutil.KubernetesClient, pgSpec acidv1.Postgres
 		KubeClient:          kubeClient,
 		currentMajorVersion: 0,
 		replicationSlots:    make(map[string]interface{}),
+
+		crashArtifactsCollected: make(map[string]time.Time),
 	}
 	cluster.logger = logger.WithField("pkg", "cluster").WithField("cluster-name", cluster.clusterName())
 	cluster.teamsAPIClient = teams.NewTeamsAPI(cfg.OpConfig.TeamsAPIUrl, logger)
@@ -403,6 +414,22 @@ func (c *Cluster) Create() (err error) {
 		c.logger.Info("a k8s cron job for logical backup has been successfully created")
 	}
 
+	if c.OpConfig.EnableCheckJobs && len(c.Spec.CheckJobs) > 0 {
+		if err := c.syncCheckJobs(); err != nil {
+			c.logger.Warningf("could not create check jobs: %v", err)
+		} else {
+			c.logger.Info("k8s cron jobs for check jobs have been successfully created")
+		}
+	}
+
+	if c.OpConfig.EnablePrometheusRules {
+		if err := c.syncPrometheusRule(); err != nil {
+			c.logger.Warningf("could not create PrometheusRule: %v", err)
+		} else {
+			c.logger.Infof("PrometheusRule %q has been successfully created", c.prometheusRuleName())
+		}
+	}
+
 	// Create connection pooler deployment and services if necessary. Since we
 	// need to perform some operations with the database itself (e.g. install
 	// lookup function), do it as the last step, when everything is available.
@@ -427,6 +454,14 @@ func (c *Cluster) Create() (err error) {
 		}
 	}
 
+	if err := c.syncEffectiveSpec(); err != nil {
+		c.logger.Warningf("could not sync effective spec: %v", err)
+	}
+
+	if err := c.syncManagedBy(); err != nil {
+		c.logger.Warningf("could not record operator managing the cluster: %v", err)
+	}
+
 	if err := c.listResources(); err != nil {
 		c.logger.Errorf("could not list resources: %v", err)
 	}
@@ -792,6 +827,9 @@ func (c *Cluster) compareAnnotations(old, new map[string]string, removedList *[]
 	for _, ignore := range c.OpConfig.IgnoredAnnotations {
 		ignoredAnnotations[ignore] = true
 	}
+	for _, ignore := range generationAnnotations {
+		ignoredAnnotations[ignore] = true
+	}
 
 	for key := range old {
 		if _, ok := ignoredAnnotations[key]; ok {
@@ -1091,6 +1129,12 @@ func (c *Cluster) Update(oldSpec, newSpec *acidv1.Postgresql) error {
 		updateFailed = true
 	}
 
+	// storage class migration
+	if err := c.migrateStorageClass(); err != nil {
+		c.logger.Errorf("could not migrate volumes to storage class %q: %v", c.Spec.Volume.StorageClass, err)
+		updateFailed = true
+	}
+
 	// logical backup job
 	func() {
 
@@ -1124,6 +1168,11 @@ func (c *Cluster) Update(oldSpec, newSpec *acidv1.Postgresql) error {
 
 	}()
 
+	if err := c.syncCheckJobs(); err != nil {
+		c.logger.Errorf("could not sync check jobs: %v", err)
+		updateFailed = true
+	}
+
 	// Roles and Databases
 	if !userInitFailed && !(c.databaseAccessDisabled() || c.getNumberOfInstances(&c.Spec) <= 0 || c.Spec.StandbyCluster != nil) {
 		c.logger.Debug("syncing roles")
@@ -1167,6 +1216,23 @@ func (c *Cluster) Update(oldSpec, newSpec *acidv1.Postgresql) error {
 		}
 	}
 
+	if err := c.syncPrometheusRule(); err != nil {
+		c.logger.Errorf("could not sync PrometheusRule: %v", err)
+		updateFailed = true
+	}
+
+	if err := c.syncDebugContainer(); err != nil {
+		c.logger.Errorf("could not sync debug container: %v", err)
+	}
+
+	if err := c.syncEffectiveSpec(); err != nil {
+		c.logger.Errorf("could not sync effective spec: %v", err)
+	}
+
+	if err := c.syncManagedBy(); err != nil {
+		c.logger.Errorf("could not record operator managing the cluster: %v", err)
+	}
+
 	if !updateFailed {
 		// Major version upgrade must only fire after success of earlier operations and should stay last
 		if err := c.majorVersionUpgrade(); err != nil {
@@ -1217,6 +1283,18 @@ func (c *Cluster) Delete() error {
 		c.eventRecorder.Eventf(c.GetReference(), v1.EventTypeWarning, "Delete", "could not remove the logical backup k8s cron job; %v", err)
 	}
 
+	if err := c.deleteCheckJobs(); err != nil {
+		anyErrors = true
+		c.logger.Warningf("could not remove the check jobs: %v", err)
+		c.eventRecorder.Eventf(c.GetReference(), v1.EventTypeWarning, "Delete", "could not remove the check jobs: %v", err)
+	}
+
+	// owner references remove the PrometheusRule as well, so it does not block the deletion
+	if err := c.deletePrometheusRule(); err != nil {
+		c.logger.Warningf("could not delete PrometheusRule: %v", err)
+		c.eventRecorder.Eventf(c.GetReference(), v1.EventTypeWarning, "Delete", "could not delete PrometheusRule: %v", err)
+	}
+
 	if err := c.deleteStatefulSet(); err != nil {
 		anyErrors = true
 		c.logger.Warningf("could not delete statefulset: %v", err)
@@ -1320,6 +1398,13 @@ func (c *Cluster) processPodEvent(obj interface{}, isInInitialList bool) error {
 	// with unregisterPodSubscriber closing the channel (see #1876)
 	c.podSubscribersMu.RUnlock()
 
+	if event.EventType == PodEventUpdate && c.OpConfig.EnableCrashArtifactCollection {
+		if terminated := c.crashedPostgresContainer(event.PrevPod, event.CurPod); terminated != nil &&
+			c.startCrashArtifactCollection(event.PodName.Name, terminated) {
+			go c.collectCrashArtifacts(spec.NamespacedName(event.PodName), terminated)
+		}
+	}
+
 	return nil
 }
 
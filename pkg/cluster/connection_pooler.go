@@ -483,6 +483,7 @@ func (c *Cluster) generateConnectionPoolerDeployment(connectionPooler *Connectio
 			Template: *podTemplate,
 		},
 	}
+	deployment.Annotations = c.withGenerationAnnotations(deployment.Annotations, deployment.Spec)
 
 	return deployment, nil
 }
@@ -524,6 +525,7 @@ func (c *Cluster) generateConnectionPoolerService(connectionPooler *ConnectionPo
 		},
 		Spec: serviceSpec,
 	}
+	service.Annotations = c.withGenerationAnnotations(service.Annotations, service.Spec)
 
 	return service
 }
@@ -678,7 +680,7 @@ func updateConnectionPoolerDeployment(KubeClient k8sutil.KubernetesClient, newDe
 		return updatedDeployment, nil
 	}
 
-	patchData, err := specPatch(newDeployment.Spec)
+	patchData, err := specWithGenerationPatch(newDeployment.Spec, newDeployment.Annotations)
 	if err != nil {
 		return nil, fmt.Errorf("could not form patch for the connection pooler deployment: %v", err)
 	}
@@ -37,10 +37,6 @@ func mockInstallLookupFunction(schema string, user string) error {
 	return nil
 }
 
-func boolToPointer(value bool) *bool {
-	return &value
-}
-
 func deploymentUpdated(cluster *Cluster, err error, reason SyncReason) error {
 	for _, role := range [2]PostgresRole{Master, Replica} {
 
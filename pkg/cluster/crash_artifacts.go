@@ -0,0 +1,221 @@
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/zalando/postgres-operator/pkg/spec"
+	"github.com/zalando/postgres-operator/pkg/util/constants"
+)
+
+const (
+	crashArtifactPrefix   = "crash"
+	crashArtifactLogLines = 10000
+
+	// crashArtifactInterval is the minimum time between two collections for the same pod, so a
+	// container in a crash loop does not upload the same logs and core dumps over and over again
+	crashArtifactInterval = 30 * time.Minute
+)
+
+var crashArtifactDataDirectory = constants.PostgresDataPath + "/data"
+
+// crashedPostgresContainer returns the last termination state of the Postgres container
+// if it was restarted with one of the configured exit codes between both pod versions
+func (c *Cluster) crashedPostgresContainer(prevPod, curPod *v1.Pod) *v1.ContainerStateTerminated {
+	prevStatus := postgresContainerStatus(prevPod)
+	curStatus := postgresContainerStatus(curPod)
+	if prevStatus == nil || curStatus == nil || curStatus.RestartCount <= prevStatus.RestartCount {
+		return nil
+	}
+
+	terminated := curStatus.LastTerminationState.Terminated
+	if terminated == nil {
+		return nil
+	}
+
+	for _, exitCode := range c.OpConfig.CrashArtifactExitCodes {
+		if terminated.ExitCode == exitCode {
+			return terminated
+		}
+	}
+
+	return nil
+}
+
+func postgresContainerStatus(pod *v1.Pod) *v1.ContainerStatus {
+	if pod == nil {
+		return nil
+	}
+	for i, status := range pod.Status.ContainerStatuses {
+		if status.Name == constants.PostgresContainerName {
+			return &pod.Status.ContainerStatuses[i]
+		}
+	}
+	return nil
+}
+
+// startCrashArtifactCollection reserves the collection of crash artifacts for the pod. It refuses
+// while another collection for the pod is in flight or the last one finished less than
+// crashArtifactInterval ago.
+func (c *Cluster) startCrashArtifactCollection(podName string, terminated *v1.ContainerStateTerminated) bool {
+	c.crashArtifactsMu.Lock()
+	defer c.crashArtifactsMu.Unlock()
+
+	if finished, ok := c.crashArtifactsCollected[podName]; ok {
+		if finished.IsZero() {
+			c.logger.Warningf("postgres container of pod %q exited with code %d while crash artifacts are still being collected, skipping",
+				podName, terminated.ExitCode)
+			return false
+		}
+		if time.Since(finished) < crashArtifactInterval {
+			c.logger.Warningf("postgres container of pod %q exited with code %d, skipping crash artifacts since they were collected %v ago",
+				podName, terminated.ExitCode, time.Since(finished).Round(time.Second))
+			return false
+		}
+	}
+	// the zero time marks the collection as in flight
+	c.crashArtifactsCollected[podName] = time.Time{}
+
+	return true
+}
+
+func (c *Cluster) finishCrashArtifactCollection(podName string) {
+	c.crashArtifactsMu.Lock()
+	defer c.crashArtifactsMu.Unlock()
+
+	c.crashArtifactsCollected[podName] = time.Now()
+}
+
+// crashArtifactLocation returns the key prefix under which the artifacts of one crash are stored
+func (c *Cluster) crashArtifactLocation(podName string, terminated *v1.ContainerStateTerminated) string {
+	return fmt.Sprintf("%s/%s/%s/%s/%s", crashArtifactPrefix, c.Namespace, c.Name, podName,
+		terminated.FinishedAt.UTC().Format("20060102T150405Z"))
+}
+
+// collectCrashArtifacts uploads the termination state and logs of the crashed Postgres container,
+// the pg_controldata output and optionally the latest core dump to the WAL bucket. The location
+// of the artifacts is referenced in an event of the cluster. Only S3 is supported, so clusters
+// archiving WAL to GCS or Azure do not get crash artifacts.
+func (c *Cluster) collectCrashArtifacts(podName spec.NamespacedName, terminated *v1.ContainerStateTerminated) {
+	defer c.finishCrashArtifactCollection(podName.Name)
+
+	bucket := c.OpConfig.WALES3Bucket
+	if bucket == "" {
+		c.logger.Warningf("postgres container of pod %q exited with code %d, but no S3 bucket is configured to store crash artifacts",
+			podName, terminated.ExitCode)
+		return
+	}
+
+	c.logger.Warningf("postgres container of pod %q exited with code %d, collecting crash artifacts", podName, terminated.ExitCode)
+
+	uploader, err := c.newCrashArtifactUploader(bucket)
+	if err != nil {
+		c.logger.Errorf("could not collect crash artifacts of pod %q: %v", podName, err)
+		return
+	}
+
+	location := c.crashArtifactLocation(podName.Name, terminated)
+	collected := make([]string, 0)
+	upload := func(name string, body io.Reader) {
+		_, err := uploader.Upload(&s3manager.UploadInput{
+			Bucket: aws.String(bucket),
+			Key:    aws.String(location + "/" + name),
+			Body:   body,
+		})
+		if err != nil {
+			c.logger.Errorf("could not upload crash artifact %q of pod %q: %v", name, podName, err)
+			return
+		}
+		collected = append(collected, name)
+	}
+
+	if state, err := json.MarshalIndent(terminated, "", "  "); err == nil {
+		upload("termination.json", bytes.NewReader(state))
+	}
+
+	tailLines := int64(crashArtifactLogLines)
+	logs, err := c.KubeClient.Pods(podName.Namespace).GetLogs(podName.Name, &v1.PodLogOptions{
+		Container: constants.PostgresContainerName,
+		Previous:  true,
+		TailLines: &tailLines,
+	}).Stream(context.TODO())
+	if err != nil {
+		c.logger.Warningf("could not get logs of crashed postgres container of pod %q: %v", podName, err)
+	} else {
+		upload("postgres.log", logs)
+		logs.Close()
+	}
+
+	controlData, err := c.ExecCommand(&podName, "bash", "-c", "pg_controldata -D "+crashArtifactDataDirectory)
+	if err != nil {
+		c.logger.Warningf("could not get pg_controldata output of pod %q: %v", podName, err)
+	} else {
+		upload("pg_controldata.txt", strings.NewReader(controlData))
+	}
+
+	if c.OpConfig.CrashArtifactCoreDumps {
+		c.uploadCoreDump(&podName, upload)
+	}
+
+	if len(collected) == 0 {
+		c.eventRecorder.Eventf(c.GetReference(), v1.EventTypeWarning, "PostgresCrash",
+			"postgres container of pod %q exited with code %d, collection of crash artifacts failed",
+			podName.Name, terminated.ExitCode)
+		return
+	}
+	c.eventRecorder.Eventf(c.GetReference(), v1.EventTypeWarning, "PostgresCrash",
+		"postgres container of pod %q exited with code %d, crash artifacts (%s) stored at s3://%s/%s/",
+		podName.Name, terminated.ExitCode, strings.Join(collected, ", "), bucket, location)
+}
+
+// uploadCoreDump streams the most recent core file of the data directory compressed to the bucket
+func (c *Cluster) uploadCoreDump(podName *spec.NamespacedName, upload func(name string, body io.Reader)) {
+	coreFile, err := c.ExecCommand(podName, "bash", "-c",
+		fmt.Sprintf("ls -t %s/core* 2>/dev/null | head -n 1", crashArtifactDataDirectory))
+	if err != nil {
+		c.logger.Warningf("could not look up core dumps of pod %q: %v", podName, err)
+		return
+	}
+	coreFile = strings.TrimSpace(coreFile)
+	if coreFile == "" {
+		c.logger.Infof("no core dump found in data directory of pod %q", podName)
+		return
+	}
+
+	reader, writer := io.Pipe()
+	go func() {
+		var execErr bytes.Buffer
+		err := c.execCommandStream(podName, writer, &execErr, "gzip", "-c", coreFile)
+		if err == nil && execErr.Len() > 0 {
+			err = fmt.Errorf("stderr: %v", execErr.String())
+		}
+		writer.CloseWithError(err)
+	}()
+
+	upload(coreFile[strings.LastIndex(coreFile, "/")+1:]+".gz", reader)
+	reader.Close()
+}
+
+func (c *Cluster) newCrashArtifactUploader(bucket string) (*s3manager.Uploader, error) {
+	sess, err := session.NewSession(&aws.Config{Region: aws.String(c.OpConfig.AWSRegion)})
+	if err != nil {
+		return nil, fmt.Errorf("could not establish AWS session: %v", err)
+	}
+
+	region, err := s3manager.GetBucketRegion(context.TODO(), sess, bucket, c.OpConfig.AWSRegion)
+	if err != nil {
+		return nil, fmt.Errorf("could not determine region of bucket %q: %v", bucket, err)
+	}
+
+	return s3manager.NewUploader(sess.Copy(&aws.Config{Region: aws.String(region)})), nil
+}
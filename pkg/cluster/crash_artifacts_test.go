@@ -0,0 +1,112 @@
+package cluster
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	acidv1 "github.com/zalando/postgres-operator/pkg/apis/acid.zalan.do/v1"
+	"github.com/zalando/postgres-operator/pkg/util/config"
+	"github.com/zalando/postgres-operator/pkg/util/constants"
+	"github.com/zalando/postgres-operator/pkg/util/k8sutil"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newPodWithPostgresStatus(restartCount int32, exitCode int32) *v1.Pod {
+	status := v1.ContainerStatus{
+		Name:         constants.PostgresContainerName,
+		RestartCount: restartCount,
+	}
+	if exitCode != 0 {
+		status.LastTerminationState = v1.ContainerState{
+			Terminated: &v1.ContainerStateTerminated{
+				ExitCode:   exitCode,
+				FinishedAt: metav1.NewTime(time.Date(2024, 5, 17, 8, 30, 0, 0, time.UTC)),
+			},
+		}
+	}
+	return &v1.Pod{
+		Status: v1.PodStatus{
+			ContainerStatuses: []v1.ContainerStatus{status},
+		},
+	}
+}
+
+func TestCrashedPostgresContainer(t *testing.T) {
+	cluster := New(
+		Config{
+			OpConfig: config.Config{
+				CrashArtifactExitCodes: []int32{134, 139},
+			},
+		}, k8sutil.KubernetesClient{}, acidv1.Postgresql{}, logger, eventRecorder)
+
+	tests := []struct {
+		subTest string
+		prevPod *v1.Pod
+		curPod  *v1.Pod
+		crashed bool
+	}{
+		{
+			subTest: "restart with crash exit code",
+			prevPod: newPodWithPostgresStatus(0, 0),
+			curPod:  newPodWithPostgresStatus(1, 139),
+			crashed: true,
+		},
+		{
+			subTest: "restart with other exit code",
+			prevPod: newPodWithPostgresStatus(0, 0),
+			curPod:  newPodWithPostgresStatus(1, 143),
+			crashed: false,
+		},
+		{
+			subTest: "crash was already seen before",
+			prevPod: newPodWithPostgresStatus(1, 134),
+			curPod:  newPodWithPostgresStatus(1, 134),
+			crashed: false,
+		},
+		{
+			subTest: "no previous pod",
+			prevPod: nil,
+			curPod:  newPodWithPostgresStatus(1, 134),
+			crashed: false,
+		},
+	}
+
+	for _, tt := range tests {
+		terminated := cluster.crashedPostgresContainer(tt.prevPod, tt.curPod)
+		if tt.crashed != (terminated != nil) {
+			t.Errorf("%s: expected crash detection to be %v, got %#v", tt.subTest, tt.crashed, terminated)
+		}
+	}
+}
+
+func TestCrashArtifactLocation(t *testing.T) {
+	cluster := New(Config{}, k8sutil.KubernetesClient{}, acidv1.Postgresql{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "acid-test-cluster",
+			Namespace: "default",
+		},
+	}, logger, eventRecorder)
+
+	terminated := newPodWithPostgresStatus(1, 139).Status.ContainerStatuses[0].LastTerminationState.Terminated
+	assert.Equal(t, "crash/default/acid-test-cluster/acid-test-cluster-1/20240517T083000Z",
+		cluster.crashArtifactLocation("acid-test-cluster-1", terminated))
+}
+
+func TestStartCrashArtifactCollection(t *testing.T) {
+	cluster := New(Config{}, k8sutil.KubernetesClient{}, acidv1.Postgresql{}, logger, eventRecorder)
+	terminated := newPodWithPostgresStatus(1, 139).Status.ContainerStatuses[0].LastTerminationState.Terminated
+
+	assert.True(t, cluster.startCrashArtifactCollection("acid-test-cluster-0", terminated))
+	// one collection per pod in flight
+	assert.False(t, cluster.startCrashArtifactCollection("acid-test-cluster-0", terminated))
+	assert.True(t, cluster.startCrashArtifactCollection("acid-test-cluster-1", terminated))
+
+	// no repeated collection for a crash loop
+	cluster.finishCrashArtifactCollection("acid-test-cluster-0")
+	assert.False(t, cluster.startCrashArtifactCollection("acid-test-cluster-0", terminated))
+
+	cluster.crashArtifactsCollected["acid-test-cluster-0"] = time.Now().Add(-crashArtifactInterval)
+	assert.True(t, cluster.startCrashArtifactCollection("acid-test-cluster-0", terminated))
+}
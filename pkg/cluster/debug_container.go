@@ -0,0 +1,126 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/zalando/postgres-operator/pkg/util/constants"
+)
+
+// DebugContainerAnnotation names the pod to attach a debug container to
+const DebugContainerAnnotation = "acid.zalan.do/debug-container"
+
+const debugContainerNamePrefix = "debugger-"
+
+// syncDebugContainer attaches an ephemeral debug container to the pod named in the
+// debug container annotation of the cluster manifest. The annotation is removed
+// afterwards, so every new annotation results in one new debug container.
+func (c *Cluster) syncDebugContainer() error {
+	podName, requested := c.ObjectMeta.Annotations[DebugContainerAnnotation]
+	if !requested {
+		return nil
+	}
+
+	if !c.OpConfig.EnableDebugContainers {
+		c.logger.Warningf("ignoring %s annotation because debug containers are disabled", DebugContainerAnnotation)
+		return nil
+	}
+	if c.OpConfig.DebugContainerImage == "" {
+		c.logger.Warningf("ignoring %s annotation because no debug_container_image is configured", DebugContainerAnnotation)
+		return nil
+	}
+
+	c.setProcessName("attaching debug container to pod %q", podName)
+	pod, err := c.KubeClient.Pods(c.Namespace).Get(context.TODO(), podName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("could not get pod %q to attach debug container: %v", podName, err)
+	}
+
+	if pod.Labels[c.OpConfig.ClusterNameLabel] != c.Name {
+		c.eventRecorder.Eventf(c.GetReference(), v1.EventTypeWarning, "DebugContainer",
+			"pod %q does not belong to the cluster, no debug container attached", podName)
+		return c.removeDebugContainerAnnotation()
+	}
+
+	if name := activeDebugContainer(pod); name != "" {
+		c.logger.Infof("debug container %q is still running in pod %q", name, podName)
+		return c.removeDebugContainerAnnotation()
+	}
+
+	container := c.generateDebugContainer(debugContainerNamePrefix + time.Now().UTC().Format("20060102150405"))
+	pod.Spec.EphemeralContainers = append(pod.Spec.EphemeralContainers, container)
+	if _, err = c.KubeClient.Pods(c.Namespace).UpdateEphemeralContainers(context.TODO(), podName, pod, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("could not attach debug container to pod %q: %v", podName, err)
+	}
+
+	c.logger.Infof("debug container %q attached to pod %q", container.Name, podName)
+	c.eventRecorder.Eventf(c.GetReference(), v1.EventTypeNormal, "DebugContainer",
+		"debug container %q attached to pod %q for %v", container.Name, podName, c.OpConfig.DebugContainerTTL)
+
+	return c.removeDebugContainerAnnotation()
+}
+
+// generateDebugContainer returns an ephemeral container which shares the process namespace of
+// the Postgres container and exits on its own once the configured TTL has passed
+func (c *Cluster) generateDebugContainer(name string) v1.EphemeralContainer {
+	privileged := c.OpConfig.DebugContainerPrivileged
+	ttl := int64(c.OpConfig.DebugContainerTTL.Seconds())
+
+	return v1.EphemeralContainer{
+		EphemeralContainerCommon: v1.EphemeralContainerCommon{
+			Name:            name,
+			Image:           c.OpConfig.DebugContainerImage,
+			ImagePullPolicy: v1.PullIfNotPresent,
+			Command:         []string{"sleep", strconv.FormatInt(ttl, 10)},
+			VolumeMounts: []v1.VolumeMount{
+				{
+					Name:      constants.DataVolumeName,
+					MountPath: constants.PostgresDataMount,
+				},
+			},
+			SecurityContext: &v1.SecurityContext{
+				Privileged: &privileged,
+				Capabilities: &v1.Capabilities{
+					Add: []v1.Capability{"SYS_PTRACE"},
+				},
+			},
+		},
+		TargetContainerName: constants.PostgresContainerName,
+	}
+}
+
+func (c *Cluster) removeDebugContainerAnnotation() error {
+	patch, err := json.Marshal(map[string]map[string]map[string]*string{
+		"metadata": {"annotations": {DebugContainerAnnotation: nil}}})
+	if err != nil {
+		return fmt.Errorf("could not form patch to remove %s annotation: %v", DebugContainerAnnotation, err)
+	}
+	_, err = c.KubeClient.Postgresqls(c.Namespace).Patch(context.TODO(), c.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		return fmt.Errorf("could not remove %s annotation: %v", DebugContainerAnnotation, err)
+	}
+	delete(c.ObjectMeta.Annotations, DebugContainerAnnotation)
+
+	return nil
+}
+
+// activeDebugContainer returns the name of a debug container of the pod which has not terminated yet
+func activeDebugContainer(pod *v1.Pod) string {
+	for _, status := range pod.Status.EphemeralContainerStatuses {
+		if !strings.HasPrefix(status.Name, debugContainerNamePrefix) {
+			continue
+		}
+		if status.State.Terminated == nil {
+			return status.Name
+		}
+	}
+	return ""
+}
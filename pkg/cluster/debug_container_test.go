@@ -0,0 +1,90 @@
+package cluster
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	acidv1 "github.com/zalando/postgres-operator/pkg/apis/acid.zalan.do/v1"
+	fakeacidv1 "github.com/zalando/postgres-operator/pkg/generated/clientset/versioned/fake"
+	"github.com/zalando/postgres-operator/pkg/util/config"
+	"github.com/zalando/postgres-operator/pkg/util/constants"
+	"github.com/zalando/postgres-operator/pkg/util/k8sutil"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestSyncDebugContainer(t *testing.T) {
+	clusterName := "acid-test-cluster"
+	namespace := "default"
+	podName := clusterName + "-0"
+
+	clientSet := fake.NewSimpleClientset()
+	acidClientSet := fakeacidv1.NewSimpleClientset()
+	client := k8sutil.KubernetesClient{
+		PodsGetter:        clientSet.CoreV1(),
+		PostgresqlsGetter: acidClientSet.AcidV1(),
+	}
+
+	pg := acidv1.Postgresql{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        clusterName,
+			Namespace:   namespace,
+			Annotations: map[string]string{DebugContainerAnnotation: podName},
+		},
+	}
+	_, err := acidClientSet.AcidV1().Postgresqls(namespace).Create(context.TODO(), &pg, metav1.CreateOptions{})
+	assert.NoError(t, err)
+
+	pod := v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      podName,
+			Namespace: namespace,
+			Labels:    map[string]string{"cluster-name": clusterName},
+		},
+	}
+	_, err = clientSet.CoreV1().Pods(namespace).Create(context.TODO(), &pod, metav1.CreateOptions{})
+	assert.NoError(t, err)
+
+	cluster := New(
+		Config{
+			OpConfig: config.Config{
+				DockerImage:              "spilo-image",
+				DebugContainerImage:      "debug-image",
+				EnableDebugContainers:    true,
+				DebugContainerPrivileged: true,
+				DebugContainerTTL:        30 * time.Minute,
+				Resources: config.Resources{
+					ClusterNameLabel: "cluster-name",
+				},
+			},
+		}, client, pg, logger, record.NewFakeRecorder(5))
+
+	err = cluster.syncDebugContainer()
+	assert.NoError(t, err)
+
+	updatedPod, err := clientSet.CoreV1().Pods(namespace).Get(context.TODO(), podName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, updatedPod.Spec.EphemeralContainers, 1)
+
+	debugContainer := updatedPod.Spec.EphemeralContainers[0]
+	assert.Equal(t, "debug-image", debugContainer.Image)
+	assert.Equal(t, constants.PostgresContainerName, debugContainer.TargetContainerName)
+	assert.Equal(t, []string{"sleep", "1800"}, debugContainer.Command)
+	assert.True(t, *debugContainer.SecurityContext.Privileged)
+
+	updatedPg, err := acidClientSet.AcidV1().Postgresqls(namespace).Get(context.TODO(), clusterName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.NotContains(t, updatedPg.Annotations, DebugContainerAnnotation)
+	assert.NotContains(t, cluster.ObjectMeta.Annotations, DebugContainerAnnotation)
+
+	// without the annotation nothing else is attached
+	err = cluster.syncDebugContainer()
+	assert.NoError(t, err)
+	updatedPod, err = clientSet.CoreV1().Pods(namespace).Get(context.TODO(), podName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, updatedPod.Spec.EphemeralContainers, 1)
+}
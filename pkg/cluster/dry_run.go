@@ -0,0 +1,48 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/zalando/postgres-operator/pkg/spec"
+	"github.com/zalando/postgres-operator/pkg/util/k8sutil"
+)
+
+// DryRunStatefulSet generates the statefulset of the cluster and compares it with the current one
+// like the sync does, but without applying any change
+func (c *Cluster) DryRunStatefulSet() spec.StatefulSetDryRun {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	result := spec.StatefulSetDryRun{Cluster: c.clusterName()}
+
+	sset, err := c.KubeClient.StatefulSets(c.Namespace).Get(context.TODO(), c.statefulSetName(), metav1.GetOptions{})
+	if err != nil {
+		if k8sutil.ResourceNotFound(err) {
+			result.Changed = true
+			result.Reasons = []string{"statefulset does not exist"}
+		} else {
+			result.Error = fmt.Sprintf("could not get statefulset: %v", err)
+		}
+		return result
+	}
+
+	desiredSts, err := c.generateStatefulSet(&c.Spec)
+	if err != nil {
+		result.Error = fmt.Sprintf("could not generate statefulset: %v", err)
+		return result
+	}
+
+	c.Statefulset = sset
+	cmp := c.compareStatefulSetWith(desiredSts)
+	result.Changed = !cmp.match
+	result.Replace = cmp.replace
+	result.RollingUpdate = cmp.rollingUpdate
+	if !cmp.match {
+		result.Reasons = cmp.reasons
+	}
+
+	return result
+}
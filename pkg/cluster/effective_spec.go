@@ -0,0 +1,182 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	acidv1 "github.com/zalando/postgres-operator/pkg/apis/acid.zalan.do/v1"
+	"github.com/zalando/postgres-operator/pkg/util"
+	"github.com/zalando/postgres-operator/pkg/util/constants"
+	"github.com/zalando/postgres-operator/pkg/util/k8sutil"
+)
+
+// EffectiveSpecAnnotation holds the effective spec of the cluster in the Postgresql resource
+const EffectiveSpecAnnotation = "acid.zalan.do/effective-spec"
+
+// generateEffectiveSpec returns a copy of the manifest spec in which all settings the operator
+// derives from its configuration are filled in with the values it applies to the cluster
+func (c *Cluster) generateEffectiveSpec(spec *acidv1.PostgresSpec) (*acidv1.PostgresSpec, error) {
+	effectiveSpec := spec.DeepCopy()
+
+	effectiveSpec.NumberOfInstances = c.getNumberOfInstances(spec)
+	effectiveSpec.DockerImage = util.Coalesce(spec.DockerImage, c.OpConfig.DockerImage)
+	effectiveSpec.PodPriorityClassName = util.Coalesce(
+		util.Coalesce(spec.PodPriorityClassName, spec.PodPriorityClassNameOld), c.OpConfig.PodPriorityClassName)
+	effectiveSpec.PodPriorityClassNameOld = ""
+	if spec.InitContainers == nil {
+		effectiveSpec.InitContainers = effectiveSpec.InitContainersOld
+	}
+	effectiveSpec.InitContainersOld = nil
+	effectiveSpec.Tolerations = tolerations(&effectiveSpec.Tolerations, c.OpConfig.PodToleration)
+	effectiveSpec.ShmVolume = mountShmVolumeNeeded(c.OpConfig, spec)
+
+	if spec.SpiloRunAsUser == nil {
+		effectiveSpec.SpiloRunAsUser = c.OpConfig.Resources.SpiloRunAsUser
+	}
+	if spec.SpiloRunAsGroup == nil {
+		effectiveSpec.SpiloRunAsGroup = c.OpConfig.Resources.SpiloRunAsGroup
+	}
+	if spec.SpiloFSGroup == nil {
+		effectiveSpec.SpiloFSGroup = c.OpConfig.Resources.SpiloFSGroup
+	}
+
+	effectiveSpec.EnableMasterLoadBalancer = boolToPointer(c.shouldCreateLoadBalancerForService(Master, spec))
+	effectiveSpec.EnableReplicaLoadBalancer = boolToPointer(c.shouldCreateLoadBalancerForService(Replica, spec))
+	effectiveSpec.EnableMasterPoolerLoadBalancer = boolToPointer(c.shouldCreateLoadBalancerForPoolerService(Master, spec))
+	effectiveSpec.EnableReplicaPoolerLoadBalancer = boolToPointer(c.shouldCreateLoadBalancerForPoolerService(Replica, spec))
+	effectiveSpec.UseLoadBalancer = nil
+	effectiveSpec.ReplicaLoadBalancer = nil
+
+	resources, err := c.generateResourceRequirements(spec.Resources, makeDefaultResources(&c.OpConfig), constants.PostgresContainerName)
+	if err != nil {
+		return nil, fmt.Errorf("could not generate resource requirements: %v", err)
+	}
+	effectiveSpec.Resources = resourcesFromRequirements(resources)
+
+	if spec.EnableLogicalBackup {
+		effectiveSpec.LogicalBackupSchedule = util.Coalesce(spec.LogicalBackupSchedule, c.OpConfig.LogicalBackupSchedule)
+		effectiveSpec.LogicalBackupRetention = c.getLogicalBackupRetentionTime()
+	}
+
+	effectiveSpec.EnableConnectionPooler = boolToPointer(needMasterConnectionPoolerWorker(spec))
+	effectiveSpec.EnableReplicaConnectionPooler = boolToPointer(needReplicaConnectionPoolerWorker(spec))
+	if needConnectionPooler(spec) {
+		if effectiveSpec.ConnectionPooler, err = c.generateEffectiveConnectionPooler(spec.ConnectionPooler); err != nil {
+			return nil, err
+		}
+	}
+
+	return effectiveSpec, nil
+}
+
+func (c *Cluster) generateEffectiveConnectionPooler(connectionPooler *acidv1.ConnectionPooler) (*acidv1.ConnectionPooler, error) {
+	if connectionPooler == nil {
+		connectionPooler = &acidv1.ConnectionPooler{}
+	}
+	effectivePooler := connectionPooler.DeepCopy()
+
+	numberOfInstances := util.CoalesceInt32(connectionPooler.NumberOfInstances,
+		util.CoalesceInt32(c.OpConfig.ConnectionPooler.NumberOfInstances, k8sutil.Int32ToPointer(1)))
+	if *numberOfInstances < constants.ConnectionPoolerMinInstances {
+		numberOfInstances = k8sutil.Int32ToPointer(constants.ConnectionPoolerMinInstances)
+	}
+	effectivePooler.NumberOfInstances = numberOfInstances
+	effectivePooler.Schema = util.Coalesce(connectionPooler.Schema, c.OpConfig.ConnectionPooler.Schema)
+	effectivePooler.User = c.poolerUser(&acidv1.PostgresSpec{ConnectionPooler: connectionPooler})
+	effectivePooler.Mode = util.Coalesce(connectionPooler.Mode, c.OpConfig.ConnectionPooler.Mode)
+	effectivePooler.DockerImage = util.Coalesce(connectionPooler.DockerImage, c.OpConfig.ConnectionPooler.Image)
+	effectivePooler.MaxDBConnections = util.CoalesceInt32(connectionPooler.MaxDBConnections,
+		util.CoalesceInt32(c.OpConfig.ConnectionPooler.MaxDBConnections, k8sutil.Int32ToPointer(constants.ConnectionPoolerMaxDBConnections)))
+
+	resources, err := c.generateResourceRequirements(connectionPooler.Resources,
+		makeDefaultConnectionPoolerResources(&c.OpConfig), connectionPoolerContainer)
+	if err != nil {
+		return nil, fmt.Errorf("could not generate connection pooler resource requirements: %v", err)
+	}
+	effectivePooler.Resources = resourcesFromRequirements(resources)
+
+	return effectivePooler, nil
+}
+
+func boolToPointer(value bool) *bool {
+	return &value
+}
+
+func resourcesFromRequirements(requirements *v1.ResourceRequirements) *acidv1.Resources {
+	return &acidv1.Resources{
+		ResourceRequests: resourceDescriptionFromList(requirements.Requests),
+		ResourceLimits:   resourceDescriptionFromList(requirements.Limits),
+	}
+}
+
+func resourceDescriptionFromList(resources v1.ResourceList) acidv1.ResourceDescription {
+	quantity := func(name v1.ResourceName) *string {
+		if value, exists := resources[name]; exists {
+			return k8sutil.StringToPointer(value.String())
+		}
+		return nil
+	}
+
+	return acidv1.ResourceDescription{
+		CPU:          quantity(v1.ResourceCPU),
+		Memory:       quantity(v1.ResourceMemory),
+		HugePages2Mi: quantity(v1.ResourceHugePagesPrefix + "2Mi"),
+		HugePages1Gi: quantity(v1.ResourceHugePagesPrefix + "1Gi"),
+	}
+}
+
+// EffectiveSpec returns the effective spec the operator applied to the cluster in the last sync
+func (c *Cluster) EffectiveSpec() (*acidv1.PostgresSpec, error) {
+	c.specMu.RLock()
+	defer c.specMu.RUnlock()
+	if c.effectiveSpec == nil {
+		return nil, fmt.Errorf("effective spec of cluster %q has not been generated yet", c.Name)
+	}
+	return c.effectiveSpec.DeepCopy(), nil
+}
+
+// syncEffectiveSpec remembers the effective spec of the cluster for the API and, if enabled,
+// exposes it as JSON in an annotation of the Postgresql resource for external policy engines
+func (c *Cluster) syncEffectiveSpec() error {
+	effectiveSpec, err := c.generateEffectiveSpec(&c.Spec)
+	if err != nil {
+		return fmt.Errorf("could not generate effective spec: %v", err)
+	}
+	c.specMu.Lock()
+	c.effectiveSpec = effectiveSpec
+	c.specMu.Unlock()
+
+	var desiredAnnotation *string
+	if c.OpConfig.EnableEffectiveSpecAnnotation {
+		effectiveSpecJSON, err := json.Marshal(effectiveSpec)
+		if err != nil {
+			return fmt.Errorf("could not marshal effective spec: %v", err)
+		}
+		desiredAnnotation = k8sutil.StringToPointer(string(effectiveSpecJSON))
+	}
+
+	currentAnnotation, exists := c.ObjectMeta.Annotations[EffectiveSpecAnnotation]
+	if desiredAnnotation == nil && !exists || desiredAnnotation != nil && exists && *desiredAnnotation == currentAnnotation {
+		return nil
+	}
+
+	patch, err := json.Marshal(map[string]map[string]map[string]*string{
+		"metadata": {"annotations": {EffectiveSpecAnnotation: desiredAnnotation}}})
+	if err != nil {
+		return fmt.Errorf("could not form patch for %s annotation: %v", EffectiveSpecAnnotation, err)
+	}
+	pg, err := c.KubeClient.Postgresqls(c.Namespace).Patch(context.TODO(), c.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		return fmt.Errorf("could not patch %s annotation: %v", EffectiveSpecAnnotation, err)
+	}
+	c.specMu.Lock()
+	c.ObjectMeta.Annotations = pg.Annotations
+	c.specMu.Unlock()
+
+	return nil
+}
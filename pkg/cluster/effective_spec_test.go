@@ -0,0 +1,146 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	acidv1 "github.com/zalando/postgres-operator/pkg/apis/acid.zalan.do/v1"
+	fakeacidv1 "github.com/zalando/postgres-operator/pkg/generated/clientset/versioned/fake"
+	"github.com/zalando/postgres-operator/pkg/util"
+	"github.com/zalando/postgres-operator/pkg/util/config"
+	"github.com/zalando/postgres-operator/pkg/util/k8sutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newEffectiveSpecTestCluster(client k8sutil.KubernetesClient, pg acidv1.Postgresql, annotate bool) *Cluster {
+	spiloRunAsUser := int64(101)
+	return New(
+		Config{
+			OpConfig: config.Config{
+				DockerImage:                   "spilo-image",
+				EnableMasterLoadBalancer:      true,
+				EnableEffectiveSpecAnnotation: annotate,
+				LogicalBackup: config.LogicalBackup{
+					LogicalBackupSchedule: "30 00 * * *",
+				},
+				ConnectionPooler: config.ConnectionPooler{
+					NumberOfInstances:                    k8sutil.Int32ToPointer(2),
+					Image:                                "pooler-image",
+					Mode:                                 "transaction",
+					Schema:                               "pooler",
+					User:                                 "pooler",
+					MaxDBConnections:                     k8sutil.Int32ToPointer(60),
+					ConnectionPoolerDefaultCPURequest:    "100m",
+					ConnectionPoolerDefaultMemoryRequest: "100Mi",
+					ConnectionPoolerDefaultCPULimit:      "1",
+					ConnectionPoolerDefaultMemoryLimit:   "100Mi",
+				},
+				Resources: config.Resources{
+					DefaultCPURequest:    "100m",
+					DefaultMemoryRequest: "100Mi",
+					DefaultCPULimit:      "1",
+					DefaultMemoryLimit:   "500Mi",
+					SpiloRunAsUser:       &spiloRunAsUser,
+					MinInstances:         -1,
+					MaxInstances:         3,
+				},
+			},
+		}, client, pg, logger, eventRecorder)
+}
+
+func TestGenerateEffectiveSpec(t *testing.T) {
+	pg := acidv1.Postgresql{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "acid-test-cluster",
+			Namespace: "default",
+		},
+		Spec: acidv1.PostgresSpec{
+			NumberOfInstances:   5,
+			EnableLogicalBackup: true,
+			Resources: &acidv1.Resources{
+				ResourceRequests: acidv1.ResourceDescription{CPU: k8sutil.StringToPointer("250m")},
+			},
+			ConnectionPooler: &acidv1.ConnectionPooler{
+				Mode: "session",
+			},
+			EnableReplicaLoadBalancer: util.True(),
+		},
+	}
+	cluster := newEffectiveSpecTestCluster(k8sutil.KubernetesClient{}, pg, false)
+
+	effectiveSpec, err := cluster.generateEffectiveSpec(&cluster.Spec)
+	assert.NoError(t, err)
+
+	assert.Equal(t, int32(3), effectiveSpec.NumberOfInstances)
+	assert.Equal(t, "spilo-image", effectiveSpec.DockerImage)
+	assert.Equal(t, int64(101), *effectiveSpec.SpiloRunAsUser)
+	assert.True(t, *effectiveSpec.EnableMasterLoadBalancer)
+	assert.True(t, *effectiveSpec.EnableReplicaLoadBalancer)
+	assert.Equal(t, "30 00 * * *", effectiveSpec.LogicalBackupSchedule)
+
+	assert.Equal(t, "250m", *effectiveSpec.Resources.ResourceRequests.CPU)
+	assert.Equal(t, "100Mi", *effectiveSpec.Resources.ResourceRequests.Memory)
+	assert.Equal(t, "1", *effectiveSpec.Resources.ResourceLimits.CPU)
+	assert.Equal(t, "500Mi", *effectiveSpec.Resources.ResourceLimits.Memory)
+
+	assert.True(t, *effectiveSpec.EnableConnectionPooler)
+	assert.False(t, *effectiveSpec.EnableReplicaConnectionPooler)
+	assert.Equal(t, "session", effectiveSpec.ConnectionPooler.Mode)
+	assert.Equal(t, "pooler-image", effectiveSpec.ConnectionPooler.DockerImage)
+	assert.Equal(t, int32(2), *effectiveSpec.ConnectionPooler.NumberOfInstances)
+
+	// the manifest spec itself stays untouched
+	assert.Equal(t, int32(5), cluster.Spec.NumberOfInstances)
+	assert.Equal(t, "", cluster.Spec.DockerImage)
+	assert.Nil(t, cluster.Spec.EnableMasterLoadBalancer)
+}
+
+func TestSyncEffectiveSpec(t *testing.T) {
+	clusterName := "acid-test-cluster"
+	namespace := "default"
+
+	acidClientSet := fakeacidv1.NewSimpleClientset()
+	client := k8sutil.KubernetesClient{
+		PostgresqlsGetter: acidClientSet.AcidV1(),
+	}
+
+	pg := acidv1.Postgresql{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      clusterName,
+			Namespace: namespace,
+		},
+		Spec: acidv1.PostgresSpec{
+			NumberOfInstances: 1,
+		},
+	}
+	_, err := acidClientSet.AcidV1().Postgresqls(namespace).Create(context.TODO(), &pg, metav1.CreateOptions{})
+	assert.NoError(t, err)
+
+	cluster := newEffectiveSpecTestCluster(client, pg, true)
+
+	_, err = cluster.EffectiveSpec()
+	assert.Error(t, err)
+
+	err = cluster.syncEffectiveSpec()
+	assert.NoError(t, err)
+
+	effectiveSpec, err := cluster.EffectiveSpec()
+	assert.NoError(t, err)
+	assert.Equal(t, "spilo-image", effectiveSpec.DockerImage)
+
+	updatedPg, err := acidClientSet.AcidV1().Postgresqls(namespace).Get(context.TODO(), clusterName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	effectiveSpecJSON, err := json.Marshal(effectiveSpec)
+	assert.NoError(t, err)
+	assert.Equal(t, string(effectiveSpecJSON), updatedPg.Annotations[EffectiveSpecAnnotation])
+
+	// disabling the annotation removes it from the manifest
+	cluster.OpConfig.EnableEffectiveSpecAnnotation = false
+	err = cluster.syncEffectiveSpec()
+	assert.NoError(t, err)
+	updatedPg, err = acidClientSet.AcidV1().Postgresqls(namespace).Get(context.TODO(), clusterName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.NotContains(t, updatedPg.Annotations, EffectiveSpecAnnotation)
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"strings"
 
 	v1 "k8s.io/api/core/v1"
@@ -24,9 +25,23 @@ func (c *Cluster) ExecCommand(podName *spec.NamespacedName, command ...string) (
 		execErr bytes.Buffer
 	)
 
+	if err := c.execCommandStream(podName, &execOut, &execErr, command...); err != nil {
+		return "", err
+	}
+
+	if execErr.Len() > 0 {
+		return "", fmt.Errorf("stderr: %v", execErr.String())
+	}
+
+	return execOut.String(), nil
+}
+
+// execCommandStream executes a command inside the Postgres container of the pod and
+// streams its output to the given writers instead of buffering it
+func (c *Cluster) execCommandStream(podName *spec.NamespacedName, stdout, stderr io.Writer, command ...string) error {
 	pod, err := c.KubeClient.Pods(podName.Namespace).Get(context.TODO(), podName.Name, metav1.GetOptions{})
 	if err != nil {
-		return "", fmt.Errorf("could not get pod info: %v", err)
+		return fmt.Errorf("could not get pod info: %v", err)
 	}
 
 	// iterate through all containers looking for the one running PostgreSQL.
@@ -39,7 +54,7 @@ func (c *Cluster) ExecCommand(podName *spec.NamespacedName, command ...string) (
 	}
 
 	if targetContainer < 0 {
-		return "", fmt.Errorf("could not find %s container to exec to", constants.PostgresContainerName)
+		return fmt.Errorf("could not find %s container to exec to", constants.PostgresContainerName)
 	}
 
 	req := c.KubeClient.RESTClient.Post().
@@ -56,22 +71,18 @@ func (c *Cluster) ExecCommand(podName *spec.NamespacedName, command ...string) (
 
 	exec, err := remotecommand.NewSPDYExecutor(c.RestConfig, "POST", req.URL())
 	if err != nil {
-		return "", fmt.Errorf("failed to init executor: %v", err)
+		return fmt.Errorf("failed to init executor: %v", err)
 	}
 
 	err = exec.StreamWithContext(context.TODO(), remotecommand.StreamOptions{
-		Stdout: &execOut,
-		Stderr: &execErr,
+		Stdout: stdout,
+		Stderr: stderr,
 		Tty:    false,
 	})
 
 	if err != nil {
-		return "", fmt.Errorf("could not execute: %v", err)
+		return fmt.Errorf("could not execute: %v", err)
 	}
 
-	if execErr.Len() > 0 {
-		return "", fmt.Errorf("stderr: %v", execErr.String())
-	}
-
-	return execOut.String(), nil
+	return nil
 }
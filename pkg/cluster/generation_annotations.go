@@ -0,0 +1,77 @@
+package cluster
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+const (
+	manifestGenerationAnnotation = "acid.zalan.do/manifest-generation"
+	operatorVersionAnnotation    = "acid.zalan.do/operator-version"
+	contentHashAnnotation        = "acid.zalan.do/content-hash"
+)
+
+// generationAnnotations trace generated objects back to the manifest they originate from. They
+// are refreshed whenever an object is written, but never trigger an update of the object themselves.
+var generationAnnotations = []string{
+	manifestGenerationAnnotation,
+	operatorVersionAnnotation,
+	contentHashAnnotation,
+}
+
+// withGenerationAnnotations adds the generation of the Postgresql manifest, the operator version
+// and a hash of the given content to the annotations of a generated object. Secrets and endpoints,
+// whose content is credentials or maintained by Patroni, pass no content and get no hash.
+func (c *Cluster) withGenerationAnnotations(annotations map[string]string, content interface{}) map[string]string {
+	if !c.OpConfig.EnableGenerationAnnotations {
+		return annotations
+	}
+
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[manifestGenerationAnnotation] = strconv.FormatInt(c.Generation, 10)
+	if c.OperatorVersion != "" {
+		annotations[operatorVersionAnnotation] = c.OperatorVersion
+	}
+
+	if content != nil {
+		hash, err := contentHash(content)
+		if err != nil {
+			c.logger.Warningf("could not compute content hash of generated object: %v", err)
+		} else {
+			annotations[contentHashAnnotation] = hash
+		}
+	}
+
+	return annotations
+}
+
+func contentHash(content interface{}) (string, error) {
+	data, err := json.Marshal(content)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(data)), nil
+}
+
+// specWithGenerationPatch produces a MergePatch of the object specification like specPatch,
+// which also carries the generation annotations matching the patched specification
+func specWithGenerationPatch(spec interface{}, annotations map[string]string) ([]byte, error) {
+	generation := make(map[string]string)
+	for _, key := range generationAnnotations {
+		if value, exists := annotations[key]; exists {
+			generation[key] = value
+		}
+	}
+	if len(generation) == 0 {
+		return specPatch(spec)
+	}
+
+	return json.Marshal(struct {
+		Meta interface{} `json:"metadata"`
+		Spec interface{} `json:"spec"`
+	}{map[string]interface{}{"annotations": generation}, spec})
+}
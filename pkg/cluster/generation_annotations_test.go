@@ -0,0 +1,80 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	acidv1 "github.com/zalando/postgres-operator/pkg/apis/acid.zalan.do/v1"
+	"github.com/zalando/postgres-operator/pkg/util/config"
+	"github.com/zalando/postgres-operator/pkg/util/k8sutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGenerationAnnotations(t *testing.T) {
+	pg := acidv1.Postgresql{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "acid-test-cluster",
+			Namespace:  "default",
+			Generation: 3,
+		},
+		Spec: acidv1.PostgresSpec{
+			NumberOfInstances: 2,
+		},
+	}
+
+	cluster := New(
+		Config{
+			OpConfig: config.Config{
+				EnableGenerationAnnotations: true,
+				Resources: config.Resources{
+					ClusterLabels:    map[string]string{"application": "spilo"},
+					ClusterNameLabel: "cluster-name",
+					PodRoleLabel:     "spilo-role",
+				},
+			},
+			OperatorVersion: "v1.14.0",
+		}, k8sutil.KubernetesClient{}, pg, logger, eventRecorder)
+
+	pdb := cluster.generatePrimaryPodDisruptionBudget()
+	assert.Equal(t, "3", pdb.Annotations[manifestGenerationAnnotation])
+	assert.Equal(t, "v1.14.0", pdb.Annotations[operatorVersionAnnotation])
+	assert.Contains(t, pdb.Annotations[contentHashAnnotation], "sha256:")
+
+	// the hash follows the content, not the generation
+	cluster.Generation = 4
+	unchangedPdb := cluster.generatePrimaryPodDisruptionBudget()
+	assert.Equal(t, "4", unchangedPdb.Annotations[manifestGenerationAnnotation])
+	assert.Equal(t, pdb.Annotations[contentHashAnnotation], unchangedPdb.Annotations[contentHashAnnotation])
+
+	cluster.Spec.NumberOfInstances = 0
+	changedPdb := cluster.generatePrimaryPodDisruptionBudget()
+	assert.NotEqual(t, pdb.Annotations[contentHashAnnotation], changedPdb.Annotations[contentHashAnnotation])
+
+	// generation annotations alone never trigger an update
+	changed, _ := cluster.compareAnnotations(pdb.Annotations, changedPdb.Annotations, nil)
+	assert.False(t, changed)
+
+	// endpoints are maintained by Patroni and carry no content hash
+	endpoint := cluster.generateEndpoint(Master, nil)
+	assert.Equal(t, "4", endpoint.Annotations[manifestGenerationAnnotation])
+	assert.NotContains(t, endpoint.Annotations, contentHashAnnotation)
+
+	// disabled by default
+	cluster.OpConfig.EnableGenerationAnnotations = false
+	assert.Nil(t, cluster.generatePrimaryPodDisruptionBudget().Annotations)
+}
+
+func TestSpecWithGenerationPatch(t *testing.T) {
+	spec := map[string]int{"replicas": 2}
+
+	patch, err := specWithGenerationPatch(spec, map[string]string{
+		manifestGenerationAnnotation: "3",
+		"foo":                        "bar",
+	})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"metadata":{"annotations":{"acid.zalan.do/manifest-generation":"3"}},"spec":{"replicas":2}}`, string(patch))
+
+	patch, err = specWithGenerationPatch(spec, nil)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"spec":{"replicas":2}}`, string(patch))
+}
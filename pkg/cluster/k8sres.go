@@ -1545,6 +1545,7 @@ func (c *Cluster) generateStatefulSet(spec *acidv1.PostgresSpec) (*appsv1.Statef
 			PersistentVolumeClaimRetentionPolicy: &persistentVolumeClaimRetentionPolicy,
 		},
 	}
+	statefulSet.Annotations = c.withGenerationAnnotations(statefulSet.Annotations, statefulSet.Spec)
 
 	return statefulSet, nil
 }
@@ -1939,7 +1940,7 @@ func (c *Cluster) generateSingleUserSecret(pgUser spec.PgUser) *v1.Secret {
 			Name:            c.credentialSecretName(username),
 			Namespace:       pgUser.Namespace,
 			Labels:          lbls,
-			Annotations:     c.annotationsSet(nil),
+			Annotations:     c.withGenerationAnnotations(c.annotationsSet(nil), nil),
 			OwnerReferences: ownerReferences,
 		},
 		Type: v1.SecretTypeOpaque,
@@ -2006,6 +2007,7 @@ func (c *Cluster) generateService(role PostgresRole, spec *acidv1.PostgresSpec)
 		},
 		Spec: serviceSpec,
 	}
+	service.Annotations = c.withGenerationAnnotations(service.Annotations, service.Spec)
 
 	return service
 }
@@ -2070,7 +2072,7 @@ func (c *Cluster) generateEndpoint(role PostgresRole, subsets []v1.EndpointSubse
 		ObjectMeta: metav1.ObjectMeta{
 			Name:            c.serviceName(role),
 			Namespace:       c.Namespace,
-			Annotations:     c.annotationsSet(nil),
+			Annotations:     c.withGenerationAnnotations(c.annotationsSet(nil), nil),
 			Labels:          c.roleLabelsSet(true, role),
 			OwnerReferences: c.ownerReferences(),
 		},
@@ -2231,7 +2233,7 @@ func (c *Cluster) generatePrimaryPodDisruptionBudget() *policyv1.PodDisruptionBu
 		labels[c.OpConfig.PodRoleLabel] = string(Master)
 	}
 
-	return &policyv1.PodDisruptionBudget{
+	pdb := &policyv1.PodDisruptionBudget{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:            c.PrimaryPodDisruptionBudgetName(),
 			Namespace:       c.Namespace,
@@ -2246,6 +2248,9 @@ func (c *Cluster) generatePrimaryPodDisruptionBudget() *policyv1.PodDisruptionBu
 			},
 		},
 	}
+	pdb.Annotations = c.withGenerationAnnotations(pdb.Annotations, pdb.Spec)
+
+	return pdb
 }
 
 func (c *Cluster) generateCriticalOpPodDisruptionBudget() *policyv1.PodDisruptionBudget {
@@ -2260,7 +2265,7 @@ func (c *Cluster) generateCriticalOpPodDisruptionBudget() *policyv1.PodDisruptio
 	labels := c.labelsSet(false)
 	labels["critical-operation"] = "true"
 
-	return &policyv1.PodDisruptionBudget{
+	pdb := &policyv1.PodDisruptionBudget{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:            c.criticalOpPodDisruptionBudgetName(),
 			Namespace:       c.Namespace,
@@ -2275,6 +2280,9 @@ func (c *Cluster) generateCriticalOpPodDisruptionBudget() *policyv1.PodDisruptio
 			},
 		},
 	}
+	pdb.Annotations = c.withGenerationAnnotations(pdb.Annotations, pdb.Spec)
+
+	return pdb
 }
 
 // getClusterServiceConnectionParameters fetches cluster host name and port
@@ -2411,6 +2419,7 @@ func (c *Cluster) generateLogicalBackupJob() (*batchv1.CronJob, error) {
 			ConcurrencyPolicy: batchv1.ForbidConcurrent,
 		},
 	}
+	cronJob.Annotations = c.withGenerationAnnotations(cronJob.Annotations, cronJob.Spec)
 
 	return cronJob, nil
 }
@@ -0,0 +1,218 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/zalando/postgres-operator/pkg/util/k8sutil"
+)
+
+const (
+	// patroniLeaderAnnotation holds the leader in the master endpoint used as leader lock by Patroni
+	patroniLeaderAnnotation = "leader"
+
+	podDeletionCostAnnotation = "controller.kubernetes.io/pod-deletion-cost"
+	leaderPodDeletionCost     = "1"
+)
+
+// AdoptLeader aligns the resources following the Patroni leader with the leader Patroni reports.
+// Patroni may have failed over while the operator was not running, so the controller does this on
+// startup ahead of the full sync of the cluster. It covers the master endpoint, the services, the
+// role selector of the primary pod disruption budget and the pod deletion cost annotations.
+func (c *Cluster) AdoptLeader() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.setProcessName("adopting Patroni leader")
+
+	pods, err := c.listPods()
+	if err != nil {
+		return fmt.Errorf("could not list pods: %v", err)
+	}
+	leader := c.patroniLeader(pods)
+	if leader == nil {
+		c.logger.Debugf("not adopting Patroni leader, no leader reported by the %d pods", len(pods))
+		return nil
+	}
+
+	if !c.patroniKubernetesUseConfigMaps() {
+		if err := c.adoptLeaderEndpoint(leader); err != nil {
+			return err
+		}
+	}
+	for _, role := range []PostgresRole{Master, Replica} {
+		if err := c.syncService(role); err != nil {
+			return fmt.Errorf("could not sync %s service: %v", role, err)
+		}
+	}
+	if err := c.adoptLeaderPodDisruptionBudget(); err != nil {
+		return err
+	}
+
+	return c.syncPodDeletionCost(pods, leader.Name)
+}
+
+// patroniLeader returns the pod Patroni reports as leader, asking one pod after another
+func (c *Cluster) patroniLeader(pods []v1.Pod) *v1.Pod {
+	for i := range pods {
+		if pods[i].Status.PodIP == "" {
+			continue
+		}
+		members, err := c.patroni.GetClusterMembers(&pods[i])
+		if err != nil {
+			c.logger.Debugf("could not get Patroni cluster members from pod %q: %v", pods[i].Name, err)
+			continue
+		}
+		for _, member := range members {
+			if role := PostgresRole(member.Role); role != Leader && role != StandbyLeader {
+				continue
+			}
+			for j := range pods {
+				if pods[j].Name == member.Name {
+					return &pods[j]
+				}
+			}
+		}
+		return nil
+	}
+	return nil
+}
+
+func endpointsContainIP(ep *v1.Endpoints, ip string) bool {
+	for _, subset := range ep.Subsets {
+		for _, address := range subset.Addresses {
+			if address.IP == ip {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// adoptLeaderEndpoint points the master endpoint to the leader pod. Patroni uses the endpoint as
+// leader lock, so the subsets are only corrected when the lock already names the leader.
+func (c *Cluster) adoptLeaderEndpoint(leader *v1.Pod) error {
+	ep, err := c.KubeClient.Endpoints(c.Namespace).Get(context.TODO(), c.serviceName(Master), metav1.GetOptions{})
+	if err != nil {
+		if k8sutil.ResourceNotFound(err) {
+			// a missing endpoint is created by the service sync
+			return nil
+		}
+		return fmt.Errorf("could not get master endpoint: %v", err)
+	}
+	if leader.Status.PodIP == "" || endpointsContainIP(ep, leader.Status.PodIP) {
+		return nil
+	}
+	if lock := ep.Annotations[patroniLeaderAnnotation]; lock != leader.Name {
+		c.logger.Warningf("not adopting leader pod %q, the master endpoint names %q as leader", leader.Name, lock)
+		return nil
+	}
+
+	c.logger.Warningf("master endpoint does not point to leader pod %q, adopting leader change", leader.Name)
+	c.eventRecorder.Eventf(c.GetReference(), v1.EventTypeNormal, "LeaderAdoption",
+		"Pointing master endpoint to leader pod %q", leader.Name)
+
+	ep.Subsets = []v1.EndpointSubset{
+		{
+			Addresses: []v1.EndpointAddress{{IP: leader.Status.PodIP}},
+			Ports:     []v1.EndpointPort{{Name: "postgresql", Port: pgPort, Protocol: v1.ProtocolTCP}},
+		},
+	}
+	ep, err = c.KubeClient.Endpoints(c.Namespace).Update(context.TODO(), ep, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("could not update master endpoint: %v", err)
+	}
+	c.Endpoints[Master] = ep
+
+	return nil
+}
+
+// syncPodDeletionCost gives the leader pod a higher pod deletion cost than the replicas, so
+// controllers honoring the annotation remove replicas first
+func (c *Cluster) syncPodDeletionCost(pods []v1.Pod, leaderName string) error {
+	for _, pod := range pods {
+		var desired *string
+		if pod.Name == leaderName {
+			desired = k8sutil.StringToPointer(leaderPodDeletionCost)
+		}
+		current, exists := pod.Annotations[podDeletionCostAnnotation]
+		if (desired == nil && !exists) || (desired != nil && exists && current == *desired) {
+			continue
+		}
+
+		patch, err := json.Marshal(map[string]map[string]map[string]*string{
+			"metadata": {"annotations": {podDeletionCostAnnotation: desired}}})
+		if err != nil {
+			return fmt.Errorf("could not form patch for pod deletion cost: %v", err)
+		}
+		if _, err = c.KubeClient.Pods(c.Namespace).Patch(context.TODO(), pod.Name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+			return fmt.Errorf("could not patch pod deletion cost of pod %q: %v", pod.Name, err)
+		}
+	}
+	return nil
+}
+
+// syncLeaderPodDeletionCost keeps the pod deletion cost annotations in line with the master pod
+func (c *Cluster) syncLeaderPodDeletionCost() error {
+	pods, err := c.listPods()
+	if err != nil {
+		return fmt.Errorf("could not list pods: %v", err)
+	}
+	masterPods := make([]string, 0)
+	for _, pod := range pods {
+		if PostgresRole(pod.Labels[c.OpConfig.PodRoleLabel]) == Master {
+			masterPods = append(masterPods, pod.Name)
+		}
+	}
+	if len(masterPods) != 1 {
+		return nil
+	}
+	return c.syncPodDeletionCost(pods, masterPods[0])
+}
+
+// adoptLeaderPodDisruptionBudget makes the primary pod disruption budget select the pods by role
+// as configured. The selector does not change on failover, but a budget selecting pods without
+// the role, e.g. left by an older operator version, would not protect the new leader.
+func (c *Cluster) adoptLeaderPodDisruptionBudget() error {
+	pdb, err := c.KubeClient.PodDisruptionBudgets(c.Namespace).Get(context.TODO(), c.PrimaryPodDisruptionBudgetName(), metav1.GetOptions{})
+	if err != nil {
+		if k8sutil.ResourceNotFound(err) {
+			// a missing pod disruption budget is created by the sync
+			return nil
+		}
+		return fmt.Errorf("could not get primary pod disruption budget: %v", err)
+	}
+
+	roleLabel := c.OpConfig.PodRoleLabel
+	desiredRole, selectRole := c.generatePrimaryPodDisruptionBudget().Spec.Selector.MatchLabels[roleLabel]
+	if pdb.Spec.Selector == nil {
+		pdb.Spec.Selector = &metav1.LabelSelector{}
+	}
+	currentRole, selectsRole := pdb.Spec.Selector.MatchLabels[roleLabel]
+	if selectRole == selectsRole && desiredRole == currentRole {
+		c.PrimaryPodDisruptionBudget = pdb
+		return nil
+	}
+
+	c.logger.Infof("adopting role selector of primary pod disruption budget %q", pdb.Name)
+	if selectRole {
+		if pdb.Spec.Selector.MatchLabels == nil {
+			pdb.Spec.Selector.MatchLabels = make(map[string]string)
+		}
+		pdb.Spec.Selector.MatchLabels[roleLabel] = desiredRole
+	} else {
+		delete(pdb.Spec.Selector.MatchLabels, roleLabel)
+	}
+	pdb, err = c.KubeClient.PodDisruptionBudgets(c.Namespace).Update(context.TODO(), pdb, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("could not update primary pod disruption budget: %v", err)
+	}
+	c.PrimaryPodDisruptionBudget = pdb
+
+	return nil
+}
@@ -0,0 +1,146 @@
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/zalando/postgres-operator/mocks"
+	acidv1 "github.com/zalando/postgres-operator/pkg/apis/acid.zalan.do/v1"
+	"github.com/zalando/postgres-operator/pkg/util/config"
+	"github.com/zalando/postgres-operator/pkg/util/k8sutil"
+	"github.com/zalando/postgres-operator/pkg/util/patroni"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestAdoptLeader(t *testing.T) {
+	clusterName := "acid-test-cluster"
+	namespace := "default"
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	clientSet := fake.NewSimpleClientset()
+	client := k8sutil.KubernetesClient{
+		EndpointsGetter:            clientSet.CoreV1(),
+		PodsGetter:                 clientSet.CoreV1(),
+		PodDisruptionBudgetsGetter: clientSet.PolicyV1(),
+		ServicesGetter:             clientSet.CoreV1(),
+	}
+
+	pg := acidv1.Postgresql{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      clusterName,
+			Namespace: namespace,
+		},
+		Spec: acidv1.PostgresSpec{
+			NumberOfInstances: 2,
+		},
+	}
+	recorder := record.NewFakeRecorder(5)
+	cluster := New(
+		Config{
+			OpConfig: config.Config{
+				PDBNameFormat: "postgres-{cluster}-pdb",
+				Resources: config.Resources{
+					ClusterLabels:    map[string]string{"application": "spilo"},
+					ClusterNameLabel: "cluster-name",
+					PodRoleLabel:     "spilo-role",
+				},
+			},
+		}, client, pg, logger, recorder)
+
+	// Patroni failed over to the second pod while the operator was not running,
+	// the role labels of the pods do not reflect it yet
+	for i, role := range []PostgresRole{Master, Replica} {
+		pod := v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("%s-%d", clusterName, i),
+				Namespace: namespace,
+				Labels: map[string]string{
+					"application":  "spilo",
+					"cluster-name": clusterName,
+					"spilo-role":   string(role),
+				},
+			},
+			Status: v1.PodStatus{
+				PodIP: fmt.Sprintf("10.0.0.%d", i+1),
+			},
+		}
+		if role == Master {
+			pod.Annotations = map[string]string{podDeletionCostAnnotation: leaderPodDeletionCost}
+		}
+		_, err := client.Pods(namespace).Create(context.TODO(), &pod, metav1.CreateOptions{})
+		assert.NoError(t, err)
+	}
+
+	membersJSON := `{"members": [
+		{"name": "acid-test-cluster-0", "role": "replica", "state": "streaming", "timeline": 2},
+		{"name": "acid-test-cluster-1", "role": "leader", "state": "running", "timeline": 2}
+	]}`
+	mockClient := mocks.NewMockHTTPClient(ctrl)
+	mockClient.EXPECT().Get(gomock.Any()).DoAndReturn(func(url string) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader([]byte(membersJSON)))}, nil
+	}).AnyTimes()
+	cluster.patroni = patroni.New(patroniLogger, mockClient)
+
+	// the leader lock does not name the new leader yet, so the endpoint is left to Patroni
+	staleEndpoint := cluster.generateEndpoint(Master, []v1.EndpointSubset{
+		{Addresses: []v1.EndpointAddress{{IP: "10.0.0.1"}}},
+	})
+	staleEndpoint.Annotations = map[string]string{patroniLeaderAnnotation: "acid-test-cluster-0"}
+	_, err := client.Endpoints(namespace).Create(context.TODO(), staleEndpoint, metav1.CreateOptions{})
+	assert.NoError(t, err)
+	stalePDB := cluster.generatePrimaryPodDisruptionBudget()
+	delete(stalePDB.Spec.Selector.MatchLabels, "spilo-role")
+	_, err = client.PodDisruptionBudgets(namespace).Create(context.TODO(), stalePDB, metav1.CreateOptions{})
+	assert.NoError(t, err)
+
+	err = cluster.AdoptLeader()
+	assert.NoError(t, err)
+	assert.Empty(t, recorder.Events)
+	ep, err := client.Endpoints(namespace).Get(context.TODO(), clusterName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.True(t, endpointsContainIP(ep, "10.0.0.1"))
+
+	// once the leader lock names the leader reported by Patroni, the subsets follow
+	ep.Annotations[patroniLeaderAnnotation] = "acid-test-cluster-1"
+	_, err = client.Endpoints(namespace).Update(context.TODO(), ep, metav1.UpdateOptions{})
+	assert.NoError(t, err)
+
+	err = cluster.AdoptLeader()
+	assert.NoError(t, err)
+	assert.Contains(t, <-recorder.Events, "LeaderAdoption")
+
+	ep, err = client.Endpoints(namespace).Get(context.TODO(), clusterName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.True(t, endpointsContainIP(ep, "10.0.0.2"))
+	assert.False(t, endpointsContainIP(ep, "10.0.0.1"))
+
+	// services, the primary pod disruption budget and the pod deletion cost are adopted as well
+	for _, role := range []PostgresRole{Master, Replica} {
+		_, err = client.Services(namespace).Get(context.TODO(), cluster.serviceName(role), metav1.GetOptions{})
+		assert.NoError(t, err)
+	}
+	pdb, err := client.PodDisruptionBudgets(namespace).Get(context.TODO(), cluster.PrimaryPodDisruptionBudgetName(), metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "master", pdb.Spec.Selector.MatchLabels["spilo-role"])
+	for i, cost := range []string{"", leaderPodDeletionCost} {
+		pod, err := client.Pods(namespace).Get(context.TODO(), fmt.Sprintf("%s-%d", clusterName, i), metav1.GetOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, cost, pod.Annotations[podDeletionCostAnnotation])
+	}
+
+	// nothing to adopt the second time
+	err = cluster.AdoptLeader()
+	assert.NoError(t, err)
+	assert.Empty(t, recorder.Events)
+}
@@ -0,0 +1,92 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	acidv1 "github.com/zalando/postgres-operator/pkg/apis/acid.zalan.do/v1"
+	"github.com/zalando/postgres-operator/pkg/util/k8sutil"
+)
+
+// annotations of the Postgresql resource recording the operator installation managing the cluster
+const (
+	ManagedByAnnotation          = "acid.zalan.do/managed-by"
+	ManagedByVersionAnnotation   = "acid.zalan.do/managed-by-version"
+	ManagedByHeartbeatAnnotation = "acid.zalan.do/managed-by-heartbeat"
+)
+
+// ManagedByAnnotations are written by the operator and must not trigger updates of the cluster
+var ManagedByAnnotations = []string{
+	ManagedByAnnotation,
+	ManagedByVersionAnnotation,
+	ManagedByHeartbeatAnnotation,
+}
+
+// ManagedByConflict returns the identity of another operator installation which recorded a
+// heartbeat in the Postgresql resource within the lease duration and thus still manages it
+func ManagedByConflict(pg *acidv1.Postgresql, identity string, leaseDuration time.Duration, now time.Time) (string, bool) {
+	owner, exists := pg.Annotations[ManagedByAnnotation]
+	if !exists || owner == "" || owner == identity {
+		return "", false
+	}
+
+	heartbeat, err := time.Parse(time.RFC3339, pg.Annotations[ManagedByHeartbeatAnnotation])
+	if err != nil || now.Sub(heartbeat) > leaseDuration {
+		return "", false
+	}
+
+	return owner, true
+}
+
+// syncManagedBy records identity and version of the operator managing the cluster in the
+// Postgresql resource. The heartbeat is renewed once half of the lease duration has passed.
+// The annotations are recorded regardless of managed_by_fencing, which only decides how
+// conflicts with other operator installations are handled.
+func (c *Cluster) syncManagedBy() error {
+	now := time.Now()
+	annotations := c.ObjectMeta.Annotations
+	desired := make(map[string]*string)
+
+	if owner := annotations[ManagedByAnnotation]; c.OperatorIdentity != "" && owner != c.OperatorIdentity {
+		if owner != "" {
+			c.logger.Infof("taking over cluster from operator %q", owner)
+		}
+		desired[ManagedByAnnotation] = k8sutil.StringToPointer(c.OperatorIdentity)
+	}
+	if version := annotations[ManagedByVersionAnnotation]; c.OperatorVersion != "" && version != c.OperatorVersion {
+		if version != "" {
+			c.logger.Infof("cluster was managed by operator version %q before, now by %q", version, c.OperatorVersion)
+		}
+		desired[ManagedByVersionAnnotation] = k8sutil.StringToPointer(c.OperatorVersion)
+	}
+	if c.OperatorIdentity != "" {
+		heartbeat, err := time.Parse(time.RFC3339, annotations[ManagedByHeartbeatAnnotation])
+		if len(desired) > 0 || err != nil || now.Sub(heartbeat) > c.OpConfig.ManagedByLeaseDuration/2 {
+			desired[ManagedByHeartbeatAnnotation] = k8sutil.StringToPointer(now.UTC().Format(time.RFC3339))
+		}
+	}
+
+	if len(desired) == 0 {
+		return nil
+	}
+
+	patch, err := json.Marshal(map[string]map[string]map[string]*string{
+		"metadata": {"annotations": desired}})
+	if err != nil {
+		return fmt.Errorf("could not form patch for %s annotations: %v", ManagedByAnnotation, err)
+	}
+	pg, err := c.KubeClient.Postgresqls(c.Namespace).Patch(context.TODO(), c.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		return fmt.Errorf("could not patch %s annotations: %v", ManagedByAnnotation, err)
+	}
+	c.specMu.Lock()
+	c.ObjectMeta.Annotations = pg.Annotations
+	c.specMu.Unlock()
+
+	return nil
+}
@@ -0,0 +1,145 @@
+package cluster
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	acidv1 "github.com/zalando/postgres-operator/pkg/apis/acid.zalan.do/v1"
+	fakeacidv1 "github.com/zalando/postgres-operator/pkg/generated/clientset/versioned/fake"
+	"github.com/zalando/postgres-operator/pkg/util/config"
+	"github.com/zalando/postgres-operator/pkg/util/k8sutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestManagedByConflict(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		subTest     string
+		annotations map[string]string
+		conflict    bool
+	}{
+		{
+			subTest:     "unmanaged cluster",
+			annotations: nil,
+			conflict:    false,
+		},
+		{
+			subTest: "managed by this operator",
+			annotations: map[string]string{
+				ManagedByAnnotation:          "default/postgres-operator",
+				ManagedByHeartbeatAnnotation: "2024-01-01T11:30:00Z",
+			},
+			conflict: false,
+		},
+		{
+			subTest: "managed by another operator",
+			annotations: map[string]string{
+				ManagedByAnnotation:          "other/postgres-operator",
+				ManagedByHeartbeatAnnotation: "2024-01-01T11:30:00Z",
+			},
+			conflict: true,
+		},
+		{
+			subTest: "lease of another operator expired",
+			annotations: map[string]string{
+				ManagedByAnnotation:          "other/postgres-operator",
+				ManagedByHeartbeatAnnotation: "2024-01-01T09:30:00Z",
+			},
+			conflict: false,
+		},
+		{
+			subTest: "another operator without heartbeat",
+			annotations: map[string]string{
+				ManagedByAnnotation: "other/postgres-operator",
+			},
+			conflict: false,
+		},
+	}
+
+	for _, tt := range tests {
+		pg := &acidv1.Postgresql{ObjectMeta: metav1.ObjectMeta{Annotations: tt.annotations}}
+		owner, conflict := ManagedByConflict(pg, "default/postgres-operator", 2*time.Hour, now)
+		assert.Equal(t, tt.conflict, conflict, tt.subTest)
+		if tt.conflict {
+			assert.Equal(t, "other/postgres-operator", owner, tt.subTest)
+		}
+	}
+}
+
+func TestSyncManagedBy(t *testing.T) {
+	clusterName := "acid-test-cluster"
+	namespace := "default"
+
+	acidClientSet := fakeacidv1.NewSimpleClientset()
+	client := k8sutil.KubernetesClient{
+		PostgresqlsGetter: acidClientSet.AcidV1(),
+	}
+
+	pg := acidv1.Postgresql{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      clusterName,
+			Namespace: namespace,
+			Annotations: map[string]string{
+				ManagedByAnnotation:          "other/postgres-operator",
+				ManagedByVersionAnnotation:   "v1.13.0",
+				ManagedByHeartbeatAnnotation: time.Now().Add(-3 * time.Hour).UTC().Format(time.RFC3339),
+			},
+		},
+	}
+	_, err := acidClientSet.AcidV1().Postgresqls(namespace).Create(context.TODO(), &pg, metav1.CreateOptions{})
+	assert.NoError(t, err)
+
+	cluster := New(
+		Config{
+			OpConfig: config.Config{
+				ManagedByFencing:       "warn",
+				ManagedByLeaseDuration: 2 * time.Hour,
+			},
+			OperatorVersion:  "v1.14.0",
+			OperatorIdentity: "default/postgres-operator",
+		}, client, pg, logger, eventRecorder)
+
+	err = cluster.syncManagedBy()
+	assert.NoError(t, err)
+
+	updatedPg, err := acidClientSet.AcidV1().Postgresqls(namespace).Get(context.TODO(), clusterName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "default/postgres-operator", updatedPg.Annotations[ManagedByAnnotation])
+	assert.Equal(t, "v1.14.0", updatedPg.Annotations[ManagedByVersionAnnotation])
+	_, conflict := ManagedByConflict(updatedPg, "other/postgres-operator", 2*time.Hour, time.Now())
+	assert.True(t, conflict)
+
+	// a recent heartbeat is not renewed, so the resource is not patched again
+	updatedPg.Annotations[ManagedByAnnotation] = "changed/postgres-operator"
+	_, err = acidClientSet.AcidV1().Postgresqls(namespace).Update(context.TODO(), updatedPg, metav1.UpdateOptions{})
+	assert.NoError(t, err)
+	err = cluster.syncManagedBy()
+	assert.NoError(t, err)
+	updatedPg, err = acidClientSet.AcidV1().Postgresqls(namespace).Get(context.TODO(), clusterName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "changed/postgres-operator", updatedPg.Annotations[ManagedByAnnotation])
+
+	// identity and version are recorded with fencing disabled as well
+	cluster.ObjectMeta.Annotations = nil
+	cluster.OpConfig.ManagedByFencing = "off"
+	cluster.OperatorVersion = "v1.15.0"
+	err = cluster.syncManagedBy()
+	assert.NoError(t, err)
+	updatedPg, err = acidClientSet.AcidV1().Postgresqls(namespace).Get(context.TODO(), clusterName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.15.0", updatedPg.Annotations[ManagedByVersionAnnotation])
+	assert.Equal(t, "default/postgres-operator", updatedPg.Annotations[ManagedByAnnotation])
+
+	// without an identity only the version is recorded
+	cluster.ObjectMeta.Annotations = nil
+	cluster.OperatorIdentity = ""
+	cluster.OperatorVersion = "v1.16.0"
+	err = cluster.syncManagedBy()
+	assert.NoError(t, err)
+	updatedPg, err = acidClientSet.AcidV1().Postgresqls(namespace).Get(context.TODO(), clusterName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.16.0", updatedPg.Annotations[ManagedByVersionAnnotation])
+	assert.Equal(t, "default/postgres-operator", updatedPg.Annotations[ManagedByAnnotation])
+}
@@ -0,0 +1,223 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/zalando/postgres-operator/pkg/util/k8sutil"
+)
+
+const (
+	prometheusRuleNameSuffix = "-alerts"
+
+	prometheusRuleReplicationLagBytes = 256 * 1024 * 1024
+	prometheusRuleDiskUsageRatio      = 0.9
+	prometheusRuleBackupMaxAgeSeconds = 26 * 60 * 60
+	prometheusRuleFailoversPerHour    = 3
+)
+
+var prometheusRuleGVR = schema.GroupVersionResource{
+	Group:    "monitoring.coreos.com",
+	Version:  "v1",
+	Resource: "prometheusrules",
+}
+
+// prometheusRuleGroup and prometheusAlertRule mirror the parts of the PrometheusRule
+// spec of the Prometheus operator which are generated for Postgres clusters
+type prometheusRuleGroup struct {
+	Name  string                `json:"name"`
+	Rules []prometheusAlertRule `json:"rules"`
+}
+
+type prometheusAlertRule struct {
+	Alert       string            `json:"alert"`
+	Expr        string            `json:"expr"`
+	For         string            `json:"for,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+func (c *Cluster) prometheusRuleName() string {
+	return c.Name + prometheusRuleNameSuffix
+}
+
+// generatePrometheusAlertRules returns the curated set of alerts for the cluster. Patroni
+// metrics are expected to carry the namespace label and the scope (cluster name) label.
+func (c *Cluster) generatePrometheusAlertRules() []prometheusAlertRule {
+	selector := fmt.Sprintf(`namespace="%s",scope="%s"`, c.Namespace, c.Name)
+	alertLabels := func(severity string) map[string]string {
+		return map[string]string{
+			"severity":                  severity,
+			"team":                      c.Spec.TeamID,
+			c.OpConfig.ClusterNameLabel: c.Name,
+		}
+	}
+
+	rules := make([]prometheusAlertRule, 0)
+
+	if c.getNumberOfInstances(&c.Spec) > 1 {
+		rules = append(rules, prometheusAlertRule{
+			Alert:  "PostgresNoRedundancy",
+			Expr:   fmt.Sprintf("sum(patroni_postgres_streaming{%s}) < 1", selector),
+			For:    "10m",
+			Labels: alertLabels("critical"),
+			Annotations: map[string]string{
+				"summary":     fmt.Sprintf("Postgres cluster %s/%s has no streaming replica", c.Namespace, c.Name),
+				"description": "None of the replicas is streaming from the primary, a failover would lose data or availability.",
+			},
+		})
+		rules = append(rules, prometheusAlertRule{
+			Alert: "PostgresReplicationLag",
+			Expr: fmt.Sprintf("max(patroni_xlog_location{%s}) - min(patroni_xlog_replayed_location{%s} > 0) > %d",
+				selector, selector, prometheusRuleReplicationLagBytes),
+			For:    "5m",
+			Labels: alertLabels("warning"),
+			Annotations: map[string]string{
+				"summary":     fmt.Sprintf("Postgres cluster %s/%s has replication lag", c.Namespace, c.Name),
+				"description": "A replica is lagging behind the primary by more than {{ $value | humanize1024 }}B.",
+			},
+		})
+	}
+
+	volumeSelector := fmt.Sprintf(`namespace="%s",persistentvolumeclaim=~"pgdata-%s-[0-9]+"`, c.Namespace, c.Name)
+	rules = append(rules, prometheusAlertRule{
+		Alert: "PostgresDiskUsage",
+		Expr: fmt.Sprintf("kubelet_volume_stats_used_bytes{%s} / kubelet_volume_stats_capacity_bytes{%s} > %g",
+			volumeSelector, volumeSelector, prometheusRuleDiskUsageRatio),
+		For:    "5m",
+		Labels: alertLabels("warning"),
+		Annotations: map[string]string{
+			"summary":     fmt.Sprintf("Postgres cluster %s/%s is running out of disk space", c.Namespace, c.Name),
+			"description": "Volume {{ $labels.persistentvolumeclaim }} is {{ $value | humanizePercentage }} full.",
+		},
+	})
+
+	if c.Spec.EnableLogicalBackup {
+		rules = append(rules, prometheusAlertRule{
+			Alert: "PostgresBackupStale",
+			Expr: fmt.Sprintf(`time() - kube_cronjob_status_last_successful_time{namespace="%s",cronjob="%s"} > %d`,
+				c.Namespace, c.getLogicalBackupJobName(), prometheusRuleBackupMaxAgeSeconds),
+			For:    "15m",
+			Labels: alertLabels("warning"),
+			Annotations: map[string]string{
+				"summary":     fmt.Sprintf("Postgres cluster %s/%s has no recent logical backup", c.Namespace, c.Name),
+				"description": "The last successful logical backup finished {{ $value | humanizeDuration }} ago.",
+			},
+		})
+	}
+
+	rules = append(rules, prometheusAlertRule{
+		Alert:  "PostgresFailoverStorm",
+		Expr:   fmt.Sprintf("changes(max(patroni_postgres_timeline{%s})[1h:1m]) >= %d", selector, prometheusRuleFailoversPerHour),
+		Labels: alertLabels("critical"),
+		Annotations: map[string]string{
+			"summary":     fmt.Sprintf("Postgres cluster %s/%s fails over repeatedly", c.Namespace, c.Name),
+			"description": "The timeline of the cluster changed {{ $value }} times within the last hour.",
+		},
+	})
+
+	return rules
+}
+
+func (c *Cluster) generatePrometheusRule() (*unstructured.Unstructured, error) {
+	groups := []prometheusRuleGroup{
+		{
+			Name:  fmt.Sprintf("postgres.%s.%s", c.Namespace, c.Name),
+			Rules: c.generatePrometheusAlertRules(),
+		},
+	}
+	spec, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&struct {
+		Groups []prometheusRuleGroup `json:"groups"`
+	}{groups})
+	if err != nil {
+		return nil, fmt.Errorf("could not convert alert rules: %v", err)
+	}
+
+	labels := c.labelsSet(true)
+	for k, v := range c.OpConfig.PrometheusRuleLabels {
+		labels[k] = v
+	}
+
+	rule := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	rule.SetAPIVersion(prometheusRuleGVR.GroupVersion().String())
+	rule.SetKind("PrometheusRule")
+	rule.SetName(c.prometheusRuleName())
+	rule.SetNamespace(c.Namespace)
+	rule.SetLabels(labels)
+	rule.SetAnnotations(c.withGenerationAnnotations(c.annotationsSet(nil), spec))
+	rule.SetOwnerReferences(c.ownerReferences())
+
+	return rule, nil
+}
+
+// syncPrometheusRule creates or updates the PrometheusRule of the cluster
+// or removes it when the generation of alert rules is disabled
+func (c *Cluster) syncPrometheusRule() error {
+	if !c.OpConfig.EnablePrometheusRules {
+		return c.deletePrometheusRule()
+	}
+
+	c.setProcessName("syncing PrometheusRule")
+	desiredRule, err := c.generatePrometheusRule()
+	if err != nil {
+		return fmt.Errorf("could not generate PrometheusRule: %v", err)
+	}
+
+	rules := c.KubeClient.DynamicClient.Resource(prometheusRuleGVR).Namespace(c.Namespace)
+	currentRule, err := rules.Get(context.TODO(), c.prometheusRuleName(), metav1.GetOptions{})
+	if err != nil {
+		if !k8sutil.ResourceNotFound(err) {
+			return fmt.Errorf("could not get PrometheusRule %q: %v", c.prometheusRuleName(), err)
+		}
+		c.logger.Infof("creating PrometheusRule %q", c.prometheusRuleName())
+		if c.PrometheusRule, err = rules.Create(context.TODO(), desiredRule, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("could not create PrometheusRule %q: %v", c.prometheusRuleName(), err)
+		}
+		return nil
+	}
+
+	if reflect.DeepEqual(currentRule.Object["spec"], desiredRule.Object["spec"]) &&
+		reflect.DeepEqual(currentRule.GetLabels(), desiredRule.GetLabels()) &&
+		reflect.DeepEqual(currentRule.GetOwnerReferences(), desiredRule.GetOwnerReferences()) {
+		c.PrometheusRule = currentRule
+		return nil
+	}
+
+	c.logger.Infof("updating PrometheusRule %q to match desired state", c.prometheusRuleName())
+	desiredRule.SetResourceVersion(currentRule.GetResourceVersion())
+	if c.PrometheusRule, err = rules.Update(context.TODO(), desiredRule, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("could not update PrometheusRule %q: %v", c.prometheusRuleName(), err)
+	}
+
+	return nil
+}
+
+// deletePrometheusRule removes the PrometheusRule created by this operator instance. Nothing is
+// requested otherwise, since the operator may lack permissions for PrometheusRules when they are
+// disabled and owner references remove the rule together with the cluster anyway.
+func (c *Cluster) deletePrometheusRule() error {
+	if c.PrometheusRule == nil {
+		return nil
+	}
+	c.setProcessName("deleting PrometheusRule")
+	c.logger.Infof("removing PrometheusRule %q", c.prometheusRuleName())
+
+	err := c.KubeClient.DynamicClient.Resource(prometheusRuleGVR).Namespace(c.Namespace).Delete(
+		context.TODO(), c.prometheusRuleName(), c.deleteOptions)
+	if k8sutil.ResourceNotFound(err) || meta.IsNoMatchError(err) || apierrors.IsForbidden(err) {
+		c.logger.Debugf("PrometheusRule %q has already been deleted or cannot be accessed: %v", c.prometheusRuleName(), err)
+	} else if err != nil {
+		return fmt.Errorf("could not delete PrometheusRule %q: %v", c.prometheusRuleName(), err)
+	}
+	c.PrometheusRule = nil
+
+	return nil
+}
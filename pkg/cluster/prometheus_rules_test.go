@@ -0,0 +1,187 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	acidv1 "github.com/zalando/postgres-operator/pkg/apis/acid.zalan.do/v1"
+	"github.com/zalando/postgres-operator/pkg/util/config"
+	"github.com/zalando/postgres-operator/pkg/util/k8sutil"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func alertNames(rules []prometheusAlertRule) []string {
+	names := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		names = append(names, rule.Alert)
+	}
+	return names
+}
+
+func TestGeneratePrometheusAlertRules(t *testing.T) {
+	tests := []struct {
+		subTest   string
+		instances int32
+		backup    bool
+		alerts    []string
+	}{
+		{
+			subTest:   "single instance without logical backup",
+			instances: 1,
+			alerts:    []string{"PostgresDiskUsage", "PostgresFailoverStorm"},
+		},
+		{
+			subTest:   "replicated cluster with logical backup",
+			instances: 2,
+			backup:    true,
+			alerts: []string{"PostgresNoRedundancy", "PostgresReplicationLag", "PostgresDiskUsage",
+				"PostgresBackupStale", "PostgresFailoverStorm"},
+		},
+	}
+
+	for _, tt := range tests {
+		cluster := New(
+			Config{
+				OpConfig: config.Config{
+					Resources: config.Resources{
+						ClusterNameLabel: "cluster-name",
+						MinInstances:     -1,
+						MaxInstances:     -1,
+					},
+				},
+			}, k8sutil.KubernetesClient{}, acidv1.Postgresql{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "acid-test-cluster",
+					Namespace: "default",
+				},
+				Spec: acidv1.PostgresSpec{
+					TeamID:              "acid",
+					NumberOfInstances:   tt.instances,
+					EnableLogicalBackup: tt.backup,
+				},
+			}, logger, eventRecorder)
+
+		rules := cluster.generatePrometheusAlertRules()
+		assert.Equal(t, tt.alerts, alertNames(rules), tt.subTest)
+		for _, rule := range rules {
+			assert.Equal(t, "acid", rule.Labels["team"], tt.subTest)
+			assert.Equal(t, "acid-test-cluster", rule.Labels["cluster-name"], tt.subTest)
+		}
+	}
+}
+
+func TestSyncPrometheusRule(t *testing.T) {
+	clusterName := "acid-test-cluster"
+	namespace := "default"
+
+	dynamicClient := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{prometheusRuleGVR: "PrometheusRuleList"})
+	client := k8sutil.KubernetesClient{
+		DynamicClient: dynamicClient,
+	}
+
+	pg := acidv1.Postgresql{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      clusterName,
+			Namespace: namespace,
+		},
+		Spec: acidv1.PostgresSpec{
+			TeamID:            "acid",
+			NumberOfInstances: 2,
+		},
+	}
+
+	cluster := New(
+		Config{
+			OpConfig: config.Config{
+				EnablePrometheusRules: true,
+				PrometheusRuleLabels:  map[string]string{"release": "prometheus"},
+				Resources: config.Resources{
+					ClusterLabels:    map[string]string{"application": "spilo"},
+					ClusterNameLabel: "cluster-name",
+					MinInstances:     -1,
+					MaxInstances:     -1,
+				},
+			},
+		}, client, pg, logger, eventRecorder)
+
+	err := cluster.syncPrometheusRule()
+	assert.NoError(t, err)
+
+	rules := dynamicClient.Resource(prometheusRuleGVR).Namespace(namespace)
+	rule, err := rules.Get(context.TODO(), clusterName+"-alerts", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "prometheus", rule.GetLabels()["release"])
+	assert.Equal(t, clusterName, rule.GetLabels()["cluster-name"])
+
+	// changing the team updates the alert labels
+	cluster.Spec.TeamID = "foo"
+	err = cluster.syncPrometheusRule()
+	assert.NoError(t, err)
+	rule, err = rules.Get(context.TODO(), clusterName+"-alerts", metav1.GetOptions{})
+	assert.NoError(t, err)
+	groups, _, _ := unstructured.NestedSlice(rule.Object, "spec", "groups")
+	assert.Len(t, groups, 1)
+	alerts, _, _ := unstructured.NestedSlice(groups[0].(map[string]interface{}), "rules")
+	team, _, _ := unstructured.NestedString(alerts[0].(map[string]interface{}), "labels", "team")
+	assert.Equal(t, "foo", team)
+
+	// disabling the option removes the rule again
+	cluster.OpConfig.EnablePrometheusRules = false
+	err = cluster.syncPrometheusRule()
+	assert.NoError(t, err)
+	_, err = rules.Get(context.TODO(), clusterName+"-alerts", metav1.GetOptions{})
+	assert.True(t, k8sutil.ResourceNotFound(err))
+	assert.Nil(t, cluster.PrometheusRule)
+}
+
+func TestDeletePrometheusRule(t *testing.T) {
+	clusterName := "acid-test-cluster"
+	namespace := "default"
+
+	dynamicClient := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{prometheusRuleGVR: "PrometheusRuleList"})
+	client := k8sutil.KubernetesClient{
+		DynamicClient: dynamicClient,
+	}
+
+	pg := acidv1.Postgresql{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      clusterName,
+			Namespace: namespace,
+		},
+	}
+	cluster := New(Config{}, client, pg, logger, eventRecorder)
+
+	rule := &unstructured.Unstructured{}
+	rule.SetAPIVersion(prometheusRuleGVR.GroupVersion().String())
+	rule.SetKind("PrometheusRule")
+	rule.SetName(clusterName + "-alerts")
+	rule.SetNamespace(namespace)
+	rules := dynamicClient.Resource(prometheusRuleGVR).Namespace(namespace)
+	_, err := rules.Create(context.TODO(), rule, metav1.CreateOptions{})
+	assert.NoError(t, err)
+	dynamicClient.ClearActions()
+
+	// rules unknown to the cluster, e.g. with the option disabled, are not requested at all
+	err = cluster.deletePrometheusRule()
+	assert.NoError(t, err)
+	assert.Empty(t, dynamicClient.Actions())
+
+	// forbidden requests do not fail the deletion
+	cluster.PrometheusRule = rule
+	dynamicClient.PrependReactor("delete", "prometheusrules", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(prometheusRuleGVR.GroupResource(), clusterName+"-alerts", fmt.Errorf("denied"))
+	})
+	err = cluster.deletePrometheusRule()
+	assert.NoError(t, err)
+	assert.Nil(t, cluster.PrometheusRule)
+}
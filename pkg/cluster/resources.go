@@ -44,6 +44,10 @@ func (c *Cluster) listResources() error {
 		c.logger.Infof("found logical backup job: %q (uid: %q)", util.NameFromMeta(c.LogicalBackupJob.ObjectMeta), c.LogicalBackupJob.UID)
 	}
 
+	for name, job := range c.CheckJobs {
+		c.logger.Infof("found check job: %q (uid: %q)", name, job.UID)
+	}
+
 	for uid, secret := range c.Secrets {
 		c.logger.Infof("found secret: %q (uid: %q) namespace: %s", util.NameFromMeta(secret.ObjectMeta), uid, secret.ObjectMeta.Namespace)
 	}
@@ -189,7 +193,7 @@ func (c *Cluster) updateStatefulSet(newStatefulSet *appsv1.StatefulSet) error {
 	}
 	c.logger.Debug("updating statefulset")
 
-	patchData, err := specPatch(newStatefulSet.Spec)
+	patchData, err := specWithGenerationPatch(newStatefulSet.Spec, newStatefulSet.Annotations)
 	if err != nil {
 		return fmt.Errorf("could not form patch for the statefulset %q: %v", statefulSetName, err)
 	}
@@ -771,7 +775,7 @@ func (c *Cluster) createLogicalBackupJob() (err error) {
 func (c *Cluster) patchLogicalBackupJob(newJob *batchv1.CronJob) error {
 	c.setProcessName("patching logical backup job")
 
-	patchData, err := specPatch(newJob.Spec)
+	patchData, err := specWithGenerationPatch(newJob.Spec, newJob.Annotations)
 	if err != nil {
 		return fmt.Errorf("could not form patch for the logical backup job: %v", err)
 	}
@@ -0,0 +1,220 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/zalando/postgres-operator/pkg/util"
+	"github.com/zalando/postgres-operator/pkg/util/constants"
+	"github.com/zalando/postgres-operator/pkg/util/k8sutil"
+	"github.com/zalando/postgres-operator/pkg/util/retryutil"
+)
+
+// StorageClassMigrationAnnotation approves moving the volumes of a cluster to the storage class
+// given in the manifest. Its value has to name the target storage class.
+const StorageClassMigrationAnnotation = "acid.zalan.do/approve-storage-class-migration"
+
+func dataVolumeClaimName(podName string) string {
+	return fmt.Sprintf("%s-%s", constants.DataVolumeName, podName)
+}
+
+// podsOnOtherStorageClass returns the pods whose data volume claim uses another storage class
+// than the given one. Pods without a claim, e.g. while it is being recreated, are skipped.
+func podsOnOtherStorageClass(pods []v1.Pod, pvcs []v1.PersistentVolumeClaim, storageClass string) []v1.Pod {
+	claims := make(map[string]*v1.PersistentVolumeClaim)
+	for i := range pvcs {
+		claims[pvcs[i].Name] = &pvcs[i]
+	}
+
+	result := make([]v1.Pod, 0)
+	for _, pod := range pods {
+		pvc, exists := claims[dataVolumeClaimName(pod.Name)]
+		if !exists {
+			continue
+		}
+		if pvc.Spec.StorageClassName == nil || *pvc.Spec.StorageClassName != storageClass {
+			result = append(result, pod)
+		}
+	}
+	return result
+}
+
+// nextStorageClassMigration picks the pod to move to the new storage class next. Replicas go
+// first, the master only once all replicas are migrated and after a switchover.
+func (c *Cluster) nextStorageClassMigration(podsToMigrate []v1.Pod) (pod *v1.Pod, switchover bool) {
+	if len(podsToMigrate) == 0 {
+		return nil, false
+	}
+	for i := range podsToMigrate {
+		if PostgresRole(podsToMigrate[i].Labels[c.OpConfig.PodRoleLabel]) != Master {
+			return &podsToMigrate[i], false
+		}
+	}
+	return &podsToMigrate[0], true
+}
+
+// waitForStorageClassMigrationMembers waits until Patroni reports all pods of the cluster as
+// running members, so a member is only re-initialized while all others are healthy
+func (c *Cluster) waitForStorageClassMigrationMembers(numberOfPods int) error {
+	return retryutil.Retry(c.OpConfig.PatroniAPICheckInterval, c.OpConfig.ResourceCheckTimeout,
+		func() (bool, error) {
+			masterPods, err := c.getRolePods(Master)
+			if err != nil || len(masterPods) != 1 {
+				return false, nil
+			}
+			members, err := c.patroni.GetClusterMembers(&masterPods[0])
+			if err != nil || len(members) != numberOfPods {
+				return false, nil
+			}
+			for _, member := range members {
+				if !util.SliceContains([]string{"running", "streaming"}, member.State) {
+					return false, nil
+				}
+			}
+			return true, nil
+		})
+}
+
+// claimsWithoutRetainedVolume returns the data volume claims of the given pods whose persistent
+// volume would be deleted together with the claim. Claims which are not bound hold no data.
+func (c *Cluster) claimsWithoutRetainedVolume(pods []v1.Pod, pvcs []v1.PersistentVolumeClaim) ([]string, error) {
+	claims := make(map[string]*v1.PersistentVolumeClaim)
+	for i := range pvcs {
+		claims[pvcs[i].Name] = &pvcs[i]
+	}
+
+	result := make([]string, 0)
+	for _, pod := range pods {
+		pvc, exists := claims[dataVolumeClaimName(pod.Name)]
+		if !exists || pvc.Spec.VolumeName == "" {
+			continue
+		}
+		pv, err := c.KubeClient.PersistentVolumes().Get(context.TODO(), pvc.Spec.VolumeName, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("could not get persistent volume %q: %v", pvc.Spec.VolumeName, err)
+		}
+		if pv.Spec.PersistentVolumeReclaimPolicy != v1.PersistentVolumeReclaimRetain {
+			result = append(result, pvc.Name)
+		}
+	}
+	return result, nil
+}
+
+// migrateVolumeOfPod removes the data volume claim of the pod and recreates the pod. The claim is
+// only gone once its pod is deleted, the statefulset then creates a new claim from its template
+// and Patroni initializes the member as a replica on the empty volume. The persistent volume of
+// the old claim is retained, so its data stays available until the volume is deleted manually.
+func (c *Cluster) migrateVolumeOfPod(pod *v1.Pod, storageClass string) error {
+	claimName := dataVolumeClaimName(pod.Name)
+	c.logger.Infof("moving volume of pod %q to storage class %q", pod.Name, storageClass)
+	c.eventRecorder.Eventf(c.GetReference(), v1.EventTypeNormal, "StorageClassMigration",
+		"Re-initializing pod %q on a new volume of storage class %q", pod.Name, storageClass)
+
+	err := c.KubeClient.PersistentVolumeClaims(c.Namespace).Delete(context.TODO(), claimName, c.deleteOptions)
+	if err != nil && !k8sutil.ResourceNotFound(err) {
+		return fmt.Errorf("could not delete persistent volume claim %q: %v", claimName, err)
+	}
+	for uid, pvc := range c.VolumeClaims {
+		if pvc.Name == claimName {
+			delete(c.VolumeClaims, uid)
+		}
+	}
+
+	if _, err := c.recreatePod(util.NameFromMeta(pod.ObjectMeta)); err != nil {
+		return fmt.Errorf("could not recreate pod %q: %v", pod.Name, err)
+	}
+	return nil
+}
+
+// migrateStorageClass moves the volumes of the cluster to the storage class from the manifest
+// one member at a time once the migration has been approved via annotation. An interrupted
+// migration continues with the next sync.
+func (c *Cluster) migrateStorageClass() error {
+	storageClass := c.Spec.Volume.StorageClass
+	if storageClass == "" || c.Statefulset == nil {
+		return nil
+	}
+
+	pods, err := c.listPods()
+	if err != nil {
+		return fmt.Errorf("could not list pods: %v", err)
+	}
+	pvcs, err := c.listPersistentVolumeClaims()
+	if err != nil {
+		return err
+	}
+	podsToMigrate := podsOnOtherStorageClass(pods, pvcs, storageClass)
+	if len(podsToMigrate) == 0 {
+		return nil
+	}
+
+	if c.ObjectMeta.Annotations[StorageClassMigrationAnnotation] != storageClass {
+		c.logger.Warningf("volumes of %d pods are not on storage class %q, set the %q annotation to %q to migrate them",
+			len(podsToMigrate), storageClass, StorageClassMigrationAnnotation, storageClass)
+		c.eventRecorder.Eventf(c.GetReference(), v1.EventTypeWarning, "StorageClassMigration",
+			"Volumes of %d pods are not on storage class %q, migration awaits approval", len(podsToMigrate), storageClass)
+		return nil
+	}
+
+	templates := c.Statefulset.Spec.VolumeClaimTemplates
+	if len(templates) == 0 || templates[0].Spec.StorageClassName == nil || *templates[0].Spec.StorageClassName != storageClass {
+		return fmt.Errorf("statefulset does not use storage class %q yet", storageClass)
+	}
+	if len(pods) < 2 {
+		return fmt.Errorf("migration without downtime requires at least two pods, found %d", len(pods))
+	}
+
+	c.setProcessName("migrating volumes to storage class %q", storageClass)
+	for len(podsToMigrate) > 0 {
+		claims, err := c.claimsWithoutRetainedVolume(podsToMigrate, pvcs)
+		if err != nil {
+			return err
+		}
+		if len(claims) > 0 {
+			c.eventRecorder.Eventf(c.GetReference(), v1.EventTypeWarning, "StorageClassMigration",
+				"Persistent volumes of claims %s are not retained, set their reclaim policy to Retain to migrate them", strings.Join(claims, ", "))
+			return fmt.Errorf("refusing to delete claims %s, whose persistent volumes do not have the Retain reclaim policy", strings.Join(claims, ", "))
+		}
+
+		if err := c.waitForStorageClassMigrationMembers(len(pods)); err != nil {
+			return fmt.Errorf("not all members of the cluster are running: %v", err)
+		}
+
+		pod, switchover := c.nextStorageClassMigration(podsToMigrate)
+		if switchover {
+			if !isInMaintenanceWindow(c.Spec.MaintenanceWindows) {
+				c.logger.Infof("postponing switchover for the storage class migration of master pod %q to the next maintenance window", pod.Name)
+				return nil
+			}
+			candidate, err := c.getSwitchoverCandidate(pod)
+			if err != nil {
+				return fmt.Errorf("could not find switchover candidate: %v", err)
+			}
+			if err := c.Switchover(pod, candidate, false); err != nil {
+				return fmt.Errorf("could not switch over from %q to %q: %v", pod.Name, candidate, err)
+			}
+		}
+
+		if err := c.migrateVolumeOfPod(pod, storageClass); err != nil {
+			return err
+		}
+
+		if pods, err = c.listPods(); err != nil {
+			return fmt.Errorf("could not list pods: %v", err)
+		}
+		if pvcs, err = c.listPersistentVolumeClaims(); err != nil {
+			return err
+		}
+		podsToMigrate = podsOnOtherStorageClass(pods, pvcs, storageClass)
+	}
+
+	c.logger.Infof("volumes of all pods are on storage class %q", storageClass)
+	c.eventRecorder.Eventf(c.GetReference(), v1.EventTypeNormal, "StorageClassMigration",
+		"Volumes of all pods moved to storage class %q", storageClass)
+
+	return nil
+}
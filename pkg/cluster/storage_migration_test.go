@@ -0,0 +1,341 @@
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/zalando/postgres-operator/mocks"
+	acidv1 "github.com/zalando/postgres-operator/pkg/apis/acid.zalan.do/v1"
+	"github.com/zalando/postgres-operator/pkg/spec"
+	"github.com/zalando/postgres-operator/pkg/util/config"
+	"github.com/zalando/postgres-operator/pkg/util/k8sutil"
+	"github.com/zalando/postgres-operator/pkg/util/patroni"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+)
+
+func newStorageMigrationTestPods(roles ...PostgresRole) []v1.Pod {
+	pods := make([]v1.Pod, 0)
+	for i, role := range roles {
+		pods = append(pods, v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("acid-test-cluster-%d", i),
+				Namespace: "default",
+				Labels: map[string]string{
+					"application":  "spilo",
+					"cluster-name": "acid-test-cluster",
+					"spilo-role":   string(role),
+				},
+			},
+			Status: v1.PodStatus{
+				PodIP: fmt.Sprintf("10.0.0.%d", i+1),
+			},
+		})
+	}
+	return pods
+}
+
+func newStorageMigrationTestClaim(podName string, storageClass *string) v1.PersistentVolumeClaim {
+	return v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      dataVolumeClaimName(podName),
+			Namespace: "default",
+			Labels: map[string]string{
+				"application":  "spilo",
+				"cluster-name": "acid-test-cluster",
+			},
+		},
+		Spec: v1.PersistentVolumeClaimSpec{
+			StorageClassName: storageClass,
+		},
+	}
+}
+
+func newStorageMigrationTestCluster(client k8sutil.KubernetesClient, recorder record.EventRecorder) *Cluster {
+	return New(
+		Config{
+			OpConfig: config.Config{
+				Resources: config.Resources{
+					ClusterLabels:    map[string]string{"application": "spilo"},
+					ClusterNameLabel: "cluster-name",
+					PodRoleLabel:     "spilo-role",
+				},
+			},
+		}, client, acidv1.Postgresql{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "acid-test-cluster",
+				Namespace: "default",
+			},
+			Spec: acidv1.PostgresSpec{
+				NumberOfInstances: 2,
+				Volume: acidv1.Volume{
+					Size:         "1Gi",
+					StorageClass: "fast",
+				},
+			},
+		}, logger, recorder)
+}
+
+func TestPodsOnOtherStorageClass(t *testing.T) {
+	pods := newStorageMigrationTestPods(Master, Replica, Replica)
+	pvcs := []v1.PersistentVolumeClaim{
+		newStorageMigrationTestClaim(pods[0].Name, k8sutil.StringToPointer("slow")),
+		newStorageMigrationTestClaim(pods[1].Name, k8sutil.StringToPointer("fast")),
+		newStorageMigrationTestClaim(pods[2].Name, nil),
+	}
+
+	result := podsOnOtherStorageClass(pods, pvcs, "fast")
+	assert.Len(t, result, 2)
+	assert.Equal(t, pods[0].Name, result[0].Name)
+	assert.Equal(t, pods[2].Name, result[1].Name)
+
+	// pods whose claim does not exist right now are not considered
+	assert.Empty(t, podsOnOtherStorageClass(pods, pvcs[1:2], "fast"))
+}
+
+func TestNextStorageClassMigration(t *testing.T) {
+	cluster := newStorageMigrationTestCluster(k8sutil.KubernetesClient{}, eventRecorder)
+
+	pod, switchover := cluster.nextStorageClassMigration(nil)
+	assert.Nil(t, pod)
+	assert.False(t, switchover)
+
+	// replicas are migrated before the master
+	pods := newStorageMigrationTestPods(Master, Replica)
+	pod, switchover = cluster.nextStorageClassMigration(pods)
+	assert.Equal(t, pods[1].Name, pod.Name)
+	assert.False(t, switchover)
+
+	// the master needs a switchover first
+	pod, switchover = cluster.nextStorageClassMigration(pods[:1])
+	assert.Equal(t, pods[0].Name, pod.Name)
+	assert.True(t, switchover)
+}
+
+func TestMigrateStorageClassApproval(t *testing.T) {
+	client, _ := newFakeK8sPVCclient()
+	recorder := record.NewFakeRecorder(10)
+	cluster := newStorageMigrationTestCluster(client, recorder)
+	cluster.Statefulset = &appsv1.StatefulSet{
+		Spec: appsv1.StatefulSetSpec{
+			VolumeClaimTemplates: []v1.PersistentVolumeClaim{
+				newStorageMigrationTestClaim("template", k8sutil.StringToPointer("slow")),
+			},
+		},
+	}
+
+	pods := newStorageMigrationTestPods(Master, Replica)
+	for _, pod := range pods {
+		_, err := client.Pods("default").Create(context.TODO(), &pod, metav1.CreateOptions{})
+		assert.NoError(t, err)
+		pvc := newStorageMigrationTestClaim(pod.Name, k8sutil.StringToPointer("slow"))
+		_, err = client.PersistentVolumeClaims("default").Create(context.TODO(), &pvc, metav1.CreateOptions{})
+		assert.NoError(t, err)
+	}
+
+	// without approval nothing is touched
+	err := cluster.migrateStorageClass()
+	assert.NoError(t, err)
+	assert.Contains(t, <-recorder.Events, "awaits approval")
+	pvcs, err := cluster.listPersistentVolumeClaims()
+	assert.NoError(t, err)
+	assert.Len(t, pvcs, 2)
+
+	// approval for another storage class does not count
+	cluster.ObjectMeta.Annotations = map[string]string{StorageClassMigrationAnnotation: "slow"}
+	err = cluster.migrateStorageClass()
+	assert.NoError(t, err)
+	assert.Contains(t, <-recorder.Events, "awaits approval")
+
+	// with approval the statefulset has to use the target storage class first
+	cluster.ObjectMeta.Annotations = map[string]string{StorageClassMigrationAnnotation: "fast"}
+	err = cluster.migrateStorageClass()
+	assert.Error(t, err)
+	pvcs, err = cluster.listPersistentVolumeClaims()
+	assert.NoError(t, err)
+	assert.Len(t, pvcs, 2)
+
+	// nothing to do once all claims are on the target storage class
+	cluster.Spec.Volume.StorageClass = "slow"
+	err = cluster.migrateStorageClass()
+	assert.NoError(t, err)
+}
+
+// deliverPodEvent passes the event to the subscriber of the pod like processPodEvent does, but
+// waits for the subscriber to listen, as the test has no informer queueing the events
+func deliverPodEvent(c *Cluster, event PodEvent) {
+	for i := 0; i < 500; i++ {
+		c.podSubscribersMu.RLock()
+		subscriber, ok := c.podSubscribers[spec.NamespacedName(event.PodName)]
+		if ok {
+			select {
+			case subscriber <- event:
+				c.podSubscribersMu.RUnlock()
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+		c.podSubscribersMu.RUnlock()
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestMigrateStorageClass(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	client, clientSet := newFakeK8sPVCclient()
+	recorder := record.NewFakeRecorder(20)
+	cluster := newStorageMigrationTestCluster(client, recorder)
+	cluster.OpConfig.PatroniAPICheckInterval = 10 * time.Millisecond
+	cluster.OpConfig.PatroniAPICheckTimeout = time.Second
+	cluster.OpConfig.ResourceCheckTimeout = time.Second
+	cluster.OpConfig.PodLabelWaitTimeout = 5 * time.Second
+	cluster.OpConfig.PodDeletionWaitTimeout = 5 * time.Second
+	cluster.ObjectMeta.Annotations = map[string]string{StorageClassMigrationAnnotation: "fast"}
+	cluster.Statefulset = &appsv1.StatefulSet{
+		Spec: appsv1.StatefulSetSpec{
+			VolumeClaimTemplates: []v1.PersistentVolumeClaim{
+				newStorageMigrationTestClaim("template", k8sutil.StringToPointer("fast")),
+			},
+		},
+	}
+
+	pods := newStorageMigrationTestPods(Master, Replica)
+	for _, pod := range pods {
+		_, err := client.Pods("default").Create(context.TODO(), &pod, metav1.CreateOptions{})
+		assert.NoError(t, err)
+		pvc := newStorageMigrationTestClaim(pod.Name, k8sutil.StringToPointer("slow"))
+		pvc.Spec.VolumeName = "pv-" + pod.Name
+		_, err = client.PersistentVolumeClaims("default").Create(context.TODO(), &pvc, metav1.CreateOptions{})
+		assert.NoError(t, err)
+		pv := v1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: pvc.Spec.VolumeName},
+			Spec: v1.PersistentVolumeSpec{
+				PersistentVolumeReclaimPolicy: v1.PersistentVolumeReclaimDelete,
+			},
+		}
+		_, err = client.PersistentVolumes().Create(context.TODO(), &pv, metav1.CreateOptions{})
+		assert.NoError(t, err)
+	}
+
+	// Patroni reports the pods with their current role as running members
+	mockClient := mocks.NewMockHTTPClient(ctrl)
+	mockClient.EXPECT().Get(gomock.Any()).DoAndReturn(func(url string) (*http.Response, error) {
+		currentPods, err := cluster.listPods()
+		if err != nil {
+			return nil, err
+		}
+		members := patroni.ClusterMembers{}
+		for _, pod := range currentPods {
+			member := patroni.ClusterMember{Name: pod.Name, Role: "replica", State: "streaming"}
+			if PostgresRole(pod.Labels["spilo-role"]) == Master {
+				member.Role, member.State = "leader", "running"
+			}
+			members.Members = append(members.Members, member)
+		}
+		body, err := json.Marshal(members)
+		if err != nil {
+			return nil, err
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(body))}, nil
+	}).AnyTimes()
+
+	// a switchover swaps the role labels and notifies the subscriber of the candidate
+	switchovers := 0
+	mockClient.EXPECT().Do(gomock.Any()).DoAndReturn(func(req *http.Request) (*http.Response, error) {
+		var request map[string]string
+		if err := json.NewDecoder(req.Body).Decode(&request); err != nil {
+			return nil, err
+		}
+		switchovers++
+		for name, role := range map[string]PostgresRole{request["leader"]: Replica, request["member"]: Master} {
+			pod, err := client.Pods("default").Get(context.TODO(), name, metav1.GetOptions{})
+			if err != nil {
+				return nil, err
+			}
+			pod.Labels["spilo-role"] = string(role)
+			if pod, err = client.Pods("default").Update(context.TODO(), pod, metav1.UpdateOptions{}); err != nil {
+				return nil, err
+			}
+			if role == Master {
+				go deliverPodEvent(cluster, PodEvent{EventType: PodEventUpdate, PodName: types.NamespacedName{Namespace: "default", Name: name}, CurPod: pod})
+			}
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader([]byte{}))}, nil
+	}).AnyTimes()
+	cluster.patroni = patroni.New(patroniLogger, mockClient)
+
+	// the statefulset brings back deleted pods as replicas with a claim from its template
+	clientSet.PrependReactor("delete", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		name := action.(k8stesting.DeleteAction).GetName()
+		go func() {
+			podName := types.NamespacedName{Namespace: "default", Name: name}
+			deliverPodEvent(cluster, PodEvent{EventType: PodEventDelete, PodName: podName})
+			pvc := newStorageMigrationTestClaim(name, k8sutil.StringToPointer("fast"))
+			pvc.Spec.VolumeName = "pv-new-" + name
+			clientSet.CoreV1().PersistentVolumeClaims("default").Create(context.TODO(), &pvc, metav1.CreateOptions{})
+			pod := newStorageMigrationTestPods(Replica)[0]
+			pod.Name = name
+			newPod, _ := clientSet.CoreV1().Pods("default").Create(context.TODO(), &pod, metav1.CreateOptions{})
+			deliverPodEvent(cluster, PodEvent{EventType: PodEventAdd, PodName: podName, CurPod: newPod})
+		}()
+		return false, nil, nil
+	})
+
+	// volumes which would be deleted together with their claim are not touched
+	err := cluster.migrateStorageClass()
+	assert.Error(t, err)
+	assert.Contains(t, <-recorder.Events, "not retained")
+	pvcs, err := cluster.listPersistentVolumeClaims()
+	assert.NoError(t, err)
+	assert.Len(t, podsOnOtherStorageClass(pods, pvcs, "fast"), 2)
+
+	for _, pod := range pods {
+		pv, err := client.PersistentVolumes().Get(context.TODO(), "pv-"+pod.Name, metav1.GetOptions{})
+		assert.NoError(t, err)
+		pv.Spec.PersistentVolumeReclaimPolicy = v1.PersistentVolumeReclaimRetain
+		_, err = client.PersistentVolumes().Update(context.TODO(), pv, metav1.UpdateOptions{})
+		assert.NoError(t, err)
+	}
+
+	// the replica is migrated first, then the master after a switchover
+	err = cluster.migrateStorageClass()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, switchovers)
+
+	currentPods, err := cluster.listPods()
+	assert.NoError(t, err)
+	assert.Len(t, currentPods, 2)
+	pvcs, err = cluster.listPersistentVolumeClaims()
+	assert.NoError(t, err)
+	assert.Empty(t, podsOnOtherStorageClass(currentPods, pvcs, "fast"))
+	masterPods, err := cluster.getRolePods(Master)
+	assert.NoError(t, err)
+	assert.Len(t, masterPods, 1)
+	assert.Equal(t, pods[1].Name, masterPods[0].Name)
+
+	// the old volumes are kept
+	for _, pod := range pods {
+		_, err := client.PersistentVolumes().Get(context.TODO(), "pv-"+pod.Name, metav1.GetOptions{})
+		assert.NoError(t, err)
+	}
+
+	// the follow-up sync has nothing left to do
+	err = cluster.migrateStorageClass()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, switchovers)
+}
@@ -122,6 +122,14 @@ func (c *Cluster) Sync(newSpec *acidv1.Postgresql) error {
 		return err
 	}
 
+	if err := c.syncLeaderPodDeletionCost(); err != nil {
+		c.logger.Errorf("could not sync pod deletion cost: %v", err)
+	}
+
+	if err := c.migrateStorageClass(); err != nil {
+		c.logger.Errorf("could not migrate volumes to storage class %q: %v", c.Spec.Volume.StorageClass, err)
+	}
+
 	// create a logical backup job unless we are running without pods or disable that feature explicitly
 	if c.Spec.EnableLogicalBackup && c.getNumberOfInstances(&c.Spec) > 0 {
 
@@ -132,6 +140,16 @@ func (c *Cluster) Sync(newSpec *acidv1.Postgresql) error {
 		}
 	}
 
+	c.logger.Debug("syncing check jobs")
+	if err := c.syncCheckJobs(); err != nil {
+		c.logger.Errorf("could not sync check jobs: %v", err)
+	}
+
+	c.logger.Debug("syncing PrometheusRule")
+	if err := c.syncPrometheusRule(); err != nil {
+		c.logger.Errorf("could not sync PrometheusRule: %v", err)
+	}
+
 	// create database objects unless we are running without pods or disabled that feature explicitly
 	if !(c.databaseAccessDisabled() || c.getNumberOfInstances(&newSpec.Spec) <= 0 || c.Spec.StandbyCluster != nil) {
 		c.logger.Debug("syncing roles")
@@ -164,6 +182,18 @@ func (c *Cluster) Sync(newSpec *acidv1.Postgresql) error {
 		}
 	}
 
+	if err := c.syncDebugContainer(); err != nil {
+		c.logger.Errorf("could not sync debug container: %v", err)
+	}
+
+	if err := c.syncEffectiveSpec(); err != nil {
+		c.logger.Errorf("could not sync effective spec: %v", err)
+	}
+
+	if err := c.syncManagedBy(); err != nil {
+		c.logger.Errorf("could not record operator managing the cluster: %v", err)
+	}
+
 	// Major version upgrade must only run after success of all earlier operations, must remain last item in sync
 	if err := c.majorVersionUpgrade(); err != nil {
 		c.logger.Errorf("major version upgrade failed: %v", err)
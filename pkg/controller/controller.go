@@ -49,6 +49,7 @@ type Controller struct {
 	stopCh chan struct{}
 
 	controllerID     string
+	operatorIdentity string
 	curWorkerID      uint32 //initialized with 0
 	curWorkerCluster sync.Map
 	clusterWorkers   map[spec.NamespacedName]uint32
@@ -70,6 +71,10 @@ type Controller struct {
 
 	workerLogs map[uint32]ringlog.RingLogger
 
+	upgradeDryRunMu   sync.RWMutex
+	upgradeDryRun     *spec.UpgradeDryRun
+	upgradeDryRunHeld map[spec.NamespacedName]bool
+
 	PodServiceAccount            *v1.ServiceAccount
 	PodServiceAccountRoleBinding *rbacv1.RoleBinding
 }
@@ -322,10 +327,14 @@ func (c *Controller) initController() {
 	} else {
 		c.initOperatorConfig()
 	}
+	if c.opConfig.EnableDebugContainers && c.opConfig.DebugContainerImage == "" {
+		c.logger.Warningf("debug containers are enabled, but no debug_container_image is configured, so none will be attached")
+	}
 	c.initPodServiceAccount()
 	c.initRoleBinding()
 
 	c.modifyConfigFromEnvironment()
+	c.operatorIdentity = c.makeOperatorIdentity()
 
 	if c.opConfig.EnableCRDRegistration != nil && *c.opConfig.EnableCRDRegistration {
 		if err := c.createPostgresCRD(); err != nil {
@@ -568,3 +577,14 @@ func (c *Controller) hasOwnership(postgresql *acidv1.Postgresql) bool {
 	}
 	return c.controllerID == ""
 }
+
+// makeOperatorIdentity distinguishes operator installations by the namespace and the configuration
+// object they run with, plus the controllerID if set. Replicas of one deployment share the identity.
+func (c *Controller) makeOperatorIdentity() string {
+	configName := util.Coalesce(os.Getenv("POSTGRES_OPERATOR_CONFIGURATION_OBJECT"), c.config.ConfigMapName.Name)
+	identity := fmt.Sprintf("%s/%s", spec.GetOperatorNamespace(), util.Coalesce(configName, "postgres-operator"))
+	if c.controllerID != "" {
+		identity = fmt.Sprintf("%s/%s", identity, c.controllerID)
+	}
+	return identity
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/sirupsen/logrus"
 
+	acidv1 "github.com/zalando/postgres-operator/pkg/apis/acid.zalan.do/v1"
 	"github.com/zalando/postgres-operator/pkg/cluster"
 	"github.com/zalando/postgres-operator/pkg/spec"
 	"github.com/zalando/postgres-operator/pkg/util"
@@ -236,3 +237,33 @@ func (c *Controller) ClusterHistory(namespace, name string) ([]*spec.Diff, error
 
 	return res, nil
 }
+
+// ClusterEffectiveSpec returns the cluster spec with all defaults from the operator configuration filled in
+func (c *Controller) ClusterEffectiveSpec(namespace, name string) (*acidv1.PostgresSpec, error) {
+
+	clusterName := spec.NamespacedName{
+		Namespace: namespace,
+		Name:      name,
+	}
+
+	c.clustersMu.RLock()
+	cl, ok := c.clusters[clusterName]
+	c.clustersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("could not find cluster")
+	}
+
+	return cl.EffectiveSpec()
+}
+
+// UpgradeDryRun returns the report of the statefulset dry run run on startup after an operator upgrade
+func (c *Controller) UpgradeDryRun() (*spec.UpgradeDryRun, error) {
+	c.upgradeDryRunMu.RLock()
+	defer c.upgradeDryRunMu.RUnlock()
+
+	if c.upgradeDryRun == nil {
+		return nil, fmt.Errorf("no upgrade dry run has been run")
+	}
+
+	return c.upgradeDryRun, nil
+}
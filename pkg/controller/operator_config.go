@@ -46,6 +46,9 @@ func (c *Controller) importConfigurationFromCRD(fromCRD *acidv1.OperatorConfigur
 	result.IgnoreInstanceLimitsAnnotationKey = fromCRD.IgnoreInstanceLimitsAnnotationKey
 	result.ResyncPeriod = util.CoalesceDuration(time.Duration(fromCRD.ResyncPeriod), "30m")
 	result.RepairPeriod = util.CoalesceDuration(time.Duration(fromCRD.RepairPeriod), "5m")
+	result.ManagedByFencing = util.Coalesce(fromCRD.ManagedByFencing, "off")
+	result.ManagedByLeaseDuration = util.CoalesceDuration(time.Duration(fromCRD.ManagedByLeaseDuration), "2h")
+	result.UpgradeDryRun = util.Coalesce(fromCRD.UpgradeDryRun, "off")
 	result.SetMemoryRequestToLimit = fromCRD.SetMemoryRequestToLimit
 	result.ShmVolume = util.CoalesceBool(fromCRD.ShmVolume, util.True())
 	result.SidecarImages = fromCRD.SidecarImages
@@ -89,6 +92,17 @@ func (c *Controller) importConfigurationFromCRD(fromCRD *acidv1.OperatorConfigur
 	result.EnableInitContainers = util.CoalesceBool(fromCRD.Kubernetes.EnableInitContainers, util.True())
 	result.EnableSidecars = util.CoalesceBool(fromCRD.Kubernetes.EnableSidecars, util.True())
 	result.SharePgSocketWithSidecars = util.CoalesceBool(fromCRD.Kubernetes.SharePgSocketWithSidecars, util.False())
+	result.EnableDebugContainers = fromCRD.Kubernetes.EnableDebugContainers
+	result.DebugContainerImage = fromCRD.Kubernetes.DebugContainerImage
+	result.DebugContainerPrivileged = fromCRD.Kubernetes.DebugContainerPrivileged
+	result.DebugContainerTTL = util.CoalesceDuration(time.Duration(fromCRD.Kubernetes.DebugContainerTTL), "1h")
+	result.EnablePrometheusRules = fromCRD.Kubernetes.EnablePrometheusRules
+	result.PrometheusRuleLabels = fromCRD.Kubernetes.PrometheusRuleLabels
+	result.EnableEffectiveSpecAnnotation = fromCRD.Kubernetes.EnableEffectiveSpecAnnotation
+	result.EnableGenerationAnnotations = fromCRD.Kubernetes.EnableGenerationAnnotations
+	result.EnableCheckJobs = fromCRD.Kubernetes.EnableCheckJobs
+	result.CheckJobPrefix = util.Coalesce(fromCRD.Kubernetes.CheckJobPrefix, "check-")
+	result.CheckJobDockerImage = fromCRD.Kubernetes.CheckJobDockerImage
 	result.SecretNameTemplate = fromCRD.Kubernetes.SecretNameTemplate
 	result.OAuthTokenSecretName = fromCRD.Kubernetes.OAuthTokenSecretName
 	result.EnableCrossNamespaceSecret = fromCRD.Kubernetes.EnableCrossNamespaceSecret
@@ -177,6 +191,13 @@ func (c *Controller) importConfigurationFromCRD(fromCRD *acidv1.OperatorConfigur
 	result.AdditionalSecretMountPath = fromCRD.AWSGCP.AdditionalSecretMountPath
 	result.EnableEBSGp3Migration = fromCRD.AWSGCP.EnableEBSGp3Migration
 	result.EnableEBSGp3MigrationMaxSize = util.CoalesceInt64(fromCRD.AWSGCP.EnableEBSGp3MigrationMaxSize, 1000)
+	result.EnableCrashArtifactCollection = fromCRD.AWSGCP.EnableCrashArtifactCollection
+	if len(fromCRD.AWSGCP.CrashArtifactExitCodes) > 0 {
+		result.CrashArtifactExitCodes = fromCRD.AWSGCP.CrashArtifactExitCodes
+	} else {
+		result.CrashArtifactExitCodes = []int32{134, 135, 136, 139}
+	}
+	result.CrashArtifactCoreDumps = fromCRD.AWSGCP.CrashArtifactCoreDumps
 
 	// logical backup config
 	result.LogicalBackupSchedule = util.Coalesce(fromCRD.LogicalBackup.Schedule, "30 00 * * *")
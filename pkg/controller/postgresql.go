@@ -144,20 +144,60 @@ func (c *Controller) acquireInitialListOfClusters() error {
 		return err
 	}
 	c.logger.Debug("acquiring initial list of clusters")
+	clusters := make([]*cluster.Cluster, 0)
 	for _, pg := range list.Items {
 		// XXX: check the cluster status field instead
 		if pg.Error != "" {
 			continue
 		}
 		clusterName = util.NameFromMeta(pg.ObjectMeta)
-		c.addCluster(c.logger, clusterName, &pg)
+		// clusters managed by another operator are added once their lease expired and they are synced
+		if c.opConfig.ManagedByFencing == "refuse" {
+			if owner, conflict := cluster.ManagedByConflict(&pg, c.operatorIdentity, c.opConfig.ManagedByLeaseDuration, time.Now()); conflict {
+				c.logger.Infof("not adding cluster %q managed by operator %q", clusterName, owner)
+				continue
+			}
+		}
+		cl, err := c.addCluster(c.logger, clusterName, &pg)
+		if err != nil {
+			continue
+		}
 		c.logger.Debugf("added new cluster: %q", clusterName)
+		clusters = append(clusters, cl)
 	}
+	// the dry run has to see the resources as left by the previous operator version
+	c.runUpgradeDryRun(list, clusters)
+	c.adoptLeaders(clusters)
 	// initiate initial sync of all clusters.
 	c.queueEvents(list, EventSync)
 	return nil
 }
 
+// adoptLeaders aligns master endpoints, services, primary pod disruption budgets and pod deletion
+// costs of all clusters with leader changes Patroni performed while the operator was not running.
+// Unlike the initial sync, which may take a while for a large number of clusters, this only involves
+// a few requests per cluster. Clusters held by the upgrade dry run are not touched.
+func (c *Controller) adoptLeaders(clusters []*cluster.Cluster) {
+	var wg sync.WaitGroup
+	workers := make(chan struct{}, max(c.opConfig.Workers, 1))
+
+	for _, cl := range clusters {
+		if c.upgradeDryRunHolds(util.NameFromMeta(cl.ObjectMeta)) {
+			continue
+		}
+		wg.Add(1)
+		workers <- struct{}{}
+		go func(cl *cluster.Cluster) {
+			defer wg.Done()
+			defer func() { <-workers }()
+			if err := cl.AdoptLeader(); err != nil {
+				c.logger.Warningf("could not adopt Patroni leader of cluster %q: %v", util.NameFromMeta(cl.ObjectMeta), err)
+			}
+		}(cl)
+	}
+	wg.Wait()
+}
+
 func (c *Controller) addCluster(lg *logrus.Entry, clusterName spec.NamespacedName, pgSpec *acidv1.Postgresql) (*cluster.Cluster, error) {
 	if c.opConfig.EnableTeamIdClusternamePrefix {
 		if _, err := acidv1.ExtractClusterName(clusterName.Name, pgSpec.Spec.TeamID); err != nil {
@@ -204,6 +244,17 @@ func (c *Controller) processEvent(event ClusterEvent) {
 
 	defer c.curWorkerCluster.Store(event.WorkerID, nil)
 
+	if event.EventType != EventDelete && !c.checkManagedBy(lg, event.NewSpec) {
+		return
+	}
+
+	if event.EventType != EventAdd && event.EventType != EventDelete && c.upgradeDryRunHolds(clusterName) {
+		lg.Warningf("statefulset would change after the operator upgrade, skipping %s event", event.EventType)
+		c.eventRecorder.Eventf(c.GetReference(event.NewSpec), v1.EventTypeWarning, "UpgradeDryRunHold",
+			"statefulset would change after the operator upgrade, skipped %s event", event.EventType)
+		return
+	}
+
 	if event.EventType == EventRepair {
 		runRepair, lastOperationStatus := cl.NeedsRepair()
 		if !runRepair {
@@ -227,7 +278,6 @@ func (c *Controller) processEvent(event ClusterEvent) {
 		if err = c.submitRBACCredentials(event); err != nil {
 			c.logger.Warnf("pods and/or Patroni may misfunction due to the lack of permissions: %v", err)
 		}
-
 	}
 
 	switch event.EventType {
@@ -541,7 +591,8 @@ func (c *Controller) postgresqlUpdate(prev, cur interface{}) {
 	if pgOld != nil && pgNew != nil {
 		// Avoid the inifinite recursion for status updates
 		if reflect.DeepEqual(pgOld.Spec, pgNew.Spec) {
-			if reflect.DeepEqual(pgNew.Annotations, pgOld.Annotations) {
+			if reflect.DeepEqual(withoutOperatorAnnotations(pgNew.Annotations), withoutOperatorAnnotations(pgOld.Annotations)) &&
+				!debugContainerRequested(pgOld, pgNew) {
 				return
 			}
 		}
@@ -549,6 +600,51 @@ func (c *Controller) postgresqlUpdate(prev, cur interface{}) {
 	}
 }
 
+// debugContainerRequested reports a new debug container request. Its removal by the operator
+// once the container is attached does not need another update of the cluster.
+func debugContainerRequested(pgOld, pgNew *acidv1.Postgresql) bool {
+	podName := pgNew.Annotations[cluster.DebugContainerAnnotation]
+	return podName != "" && podName != pgOld.Annotations[cluster.DebugContainerAnnotation]
+}
+
+// withoutOperatorAnnotations drops annotations the operator writes to the Postgresql resource
+// itself, so that updating them does not trigger another update of the cluster
+func withoutOperatorAnnotations(annotations map[string]string) map[string]string {
+	result := make(map[string]string)
+	for key, value := range annotations {
+		if key == cluster.EffectiveSpecAnnotation || key == cluster.DebugContainerAnnotation || util.SliceContains(cluster.ManagedByAnnotations, key) {
+			continue
+		}
+		result[key] = value
+	}
+	return result
+}
+
+// checkManagedBy warns when another operator installation still manages the cluster and returns
+// false if the event must not be processed to avoid both installations reverting each other
+func (c *Controller) checkManagedBy(lg *logrus.Entry, pg *acidv1.Postgresql) bool {
+	if c.opConfig.ManagedByFencing == "off" {
+		return true
+	}
+
+	owner, conflict := cluster.ManagedByConflict(pg, c.operatorIdentity, c.opConfig.ManagedByLeaseDuration, time.Now())
+	if !conflict {
+		return true
+	}
+
+	if c.opConfig.ManagedByFencing == "refuse" {
+		lg.Warningf("cluster is managed by operator %q, skipping event", owner)
+		c.eventRecorder.Eventf(c.GetReference(pg), v1.EventTypeWarning, "ManagedByConflict",
+			"operator %q refuses to manage the cluster which is managed by operator %q", c.operatorIdentity, owner)
+		return false
+	}
+
+	lg.Warningf("cluster is also managed by operator %q", owner)
+	c.eventRecorder.Eventf(c.GetReference(pg), v1.EventTypeWarning, "ManagedByConflict",
+		"cluster is managed by operators %q and %q", owner, c.operatorIdentity)
+	return true
+}
+
 func (c *Controller) postgresqlDelete(obj interface{}) {
 	pg := c.postgresqlCheck(obj)
 	if pg != nil {
@@ -7,8 +7,13 @@ import (
 	"time"
 
 	acidv1 "github.com/zalando/postgres-operator/pkg/apis/acid.zalan.do/v1"
+	"github.com/zalando/postgres-operator/pkg/cluster"
 	"github.com/zalando/postgres-operator/pkg/spec"
+	"github.com/zalando/postgres-operator/pkg/util/config"
+	"github.com/zalando/postgres-operator/pkg/util/k8sutil"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
 )
 
 var (
@@ -177,3 +182,106 @@ func TestMeetsClusterDeleteAnnotations(t *testing.T) {
 		}
 	}
 }
+
+func TestCheckManagedBy(t *testing.T) {
+	controller := newPostgresqlTestController()
+	controller.operatorIdentity = "default/postgres-operator"
+	controller.opConfig.ManagedByLeaseDuration = 2 * time.Hour
+
+	heartbeat := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	otherOperator := &acidv1.Postgresql{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "acid-test-cluster",
+			Namespace: "default",
+			Annotations: map[string]string{
+				"acid.zalan.do/managed-by":           "other/postgres-operator",
+				"acid.zalan.do/managed-by-heartbeat": heartbeat,
+			},
+		},
+	}
+	sameOperator := otherOperator.DeepCopy()
+	sameOperator.Annotations["acid.zalan.do/managed-by"] = "default/postgres-operator"
+
+	tests := []struct {
+		fencing string
+		pg      *acidv1.Postgresql
+		process bool
+	}{
+		{"warn", otherOperator, true},
+		{"refuse", otherOperator, false},
+		{"refuse", sameOperator, true},
+		{"off", otherOperator, true},
+	}
+	for _, tt := range tests {
+		controller.opConfig.ManagedByFencing = tt.fencing
+		if process := controller.checkManagedBy(controller.logger, tt.pg); process != tt.process {
+			t.Errorf("expected processing of event %t with fencing %q and manager %q, got %t",
+				tt.process, tt.fencing, tt.pg.Annotations["acid.zalan.do/managed-by"], process)
+		}
+	}
+}
+
+func TestAdoptLeadersSkipsHeldClusters(t *testing.T) {
+	clientSet := fake.NewSimpleClientset()
+	client := k8sutil.KubernetesClient{
+		PodsGetter: clientSet.CoreV1(),
+	}
+
+	controller := newPostgresqlTestController()
+	controller.upgradeDryRunHeld = map[spec.NamespacedName]bool{
+		{Namespace: "default", Name: "acid-held-cluster"}: true,
+	}
+
+	clusters := make([]*cluster.Cluster, 0)
+	for _, name := range []string{"acid-held-cluster", "acid-test-cluster"} {
+		pg := acidv1.Postgresql{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"}}
+		clusters = append(clusters, cluster.New(cluster.Config{
+			OpConfig: config.Config{
+				Resources: config.Resources{
+					ClusterNameLabel: "cluster-name",
+					PodRoleLabel:     "spilo-role",
+				},
+			},
+		}, client, pg, controller.logger, record.NewFakeRecorder(5)))
+	}
+
+	controller.adoptLeaders(clusters)
+
+	for i, adopted := range []bool{false, true} {
+		if process := clusters[i].GetCurrentProcess().Name; (process != "") != adopted {
+			t.Errorf("expected leader adoption of cluster %q to run %t, got process %q", clusters[i].Name, adopted, process)
+		}
+	}
+}
+
+func TestDebugContainerRequested(t *testing.T) {
+	withAnnotation := func(podName string) *acidv1.Postgresql {
+		pg := &acidv1.Postgresql{}
+		if podName != "" {
+			pg.Annotations = map[string]string{cluster.DebugContainerAnnotation: podName}
+		}
+		return pg
+	}
+
+	tests := []struct {
+		old       string
+		new       string
+		requested bool
+	}{
+		{"", "acid-test-cluster-0", true},
+		{"acid-test-cluster-0", "acid-test-cluster-1", true},
+		{"acid-test-cluster-0", "", false},
+		{"acid-test-cluster-0", "acid-test-cluster-0", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		pgOld, pgNew := withAnnotation(tt.old), withAnnotation(tt.new)
+		if requested := debugContainerRequested(pgOld, pgNew); requested != tt.requested {
+			t.Errorf("expected debug container requested %t when annotation changes from %q to %q, got %t",
+				tt.requested, tt.old, tt.new, requested)
+		}
+		if !reflect.DeepEqual(withoutOperatorAnnotations(pgOld.Annotations), withoutOperatorAnnotations(pgNew.Annotations)) {
+			t.Errorf("expected %s annotation to be ignored when comparing annotations", cluster.DebugContainerAnnotation)
+		}
+	}
+}
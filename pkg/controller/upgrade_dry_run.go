@@ -0,0 +1,168 @@
+package controller
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	acidv1 "github.com/zalando/postgres-operator/pkg/apis/acid.zalan.do/v1"
+	"github.com/zalando/postgres-operator/pkg/cluster"
+	"github.com/zalando/postgres-operator/pkg/spec"
+)
+
+// previousOperatorVersions returns the operator versions other than the given one, which are
+// recorded in the clusters by the operator installation managing them
+func previousOperatorVersions(list *acidv1.PostgresqlList, version string) []string {
+	versions := make(map[string]bool)
+	for _, pg := range list.Items {
+		previous := pg.Annotations[cluster.ManagedByVersionAnnotation]
+		if previous != "" && previous != version {
+			versions[previous] = true
+		}
+	}
+
+	result := make([]string, 0, len(versions))
+	for previous := range versions {
+		result = append(result, previous)
+	}
+	sort.Strings(result)
+
+	return result
+}
+
+// upgradeDryRunReason strips object specific details from a reason for changing a statefulset,
+// so the same reasons of different clusters can be counted together
+func upgradeDryRunReason(reason string) string {
+	if i := strings.Index(reason, ": "); i >= 0 {
+		return reason[:i]
+	}
+	return reason
+}
+
+func newUpgradeDryRun(results []spec.StatefulSetDryRun) *spec.UpgradeDryRun {
+	report := &spec.UpgradeDryRun{
+		Clusters: len(results),
+		Reasons:  make(map[string]int),
+		Results:  results,
+	}
+
+	for _, result := range results {
+		if result.Error != "" {
+			report.Failed++
+			continue
+		}
+		if !result.Changed {
+			continue
+		}
+		report.Changed++
+		if result.Replace {
+			report.Replace++
+		}
+		if result.RollingUpdate {
+			report.RollingUpdate++
+		}
+
+		reasons := make(map[string]bool)
+		for _, reason := range result.Reasons {
+			reasons[upgradeDryRunReason(reason)] = true
+		}
+		for reason := range reasons {
+			report.Reasons[reason]++
+		}
+	}
+
+	return report
+}
+
+func (c *Controller) dryRunStatefulSets(clusters []*cluster.Cluster) []spec.StatefulSetDryRun {
+	var wg sync.WaitGroup
+	results := make([]spec.StatefulSetDryRun, len(clusters))
+	workers := make(chan struct{}, max(c.opConfig.Workers, 1))
+
+	for i, cl := range clusters {
+		wg.Add(1)
+		workers <- struct{}{}
+		go func(i int, cl *cluster.Cluster) {
+			defer wg.Done()
+			defer func() { <-workers }()
+			results[i] = cl.DryRunStatefulSet()
+		}(i, cl)
+	}
+	wg.Wait()
+
+	return results
+}
+
+func (c *Controller) logUpgradeDryRun(report *spec.UpgradeDryRun) {
+	c.logger.Infof("upgrade dry run finished in %v: %d of %d statefulsets would change, %d would be replaced, %d need a rolling update, %d failed",
+		report.EndTime.Sub(report.StartTime).Round(time.Millisecond), report.Changed, report.Clusters, report.Replace, report.RollingUpdate, report.Failed)
+
+	reasons := make([]string, 0, len(report.Reasons))
+	for reason := range report.Reasons {
+		reasons = append(reasons, reason)
+	}
+	sort.Slice(reasons, func(i, j int) bool {
+		return report.Reasons[reasons[i]] > report.Reasons[reasons[j]]
+	})
+	for _, reason := range reasons {
+		c.logger.Infof("upgrade dry run: %d statefulsets would change because %s", report.Reasons[reason], reason)
+	}
+
+	for _, result := range report.Results {
+		if result.Error != "" {
+			c.logger.Warningf("upgrade dry run of cluster %q failed: %s", result.Cluster, result.Error)
+		} else if result.Changed {
+			c.logger.Debugf("upgrade dry run of cluster %q: %s", result.Cluster, strings.Join(result.Reasons, ", "))
+		}
+	}
+}
+
+// runUpgradeDryRun generates the statefulsets of all clusters without applying them, when the
+// operator version differs from the one recorded in any of the clusters. In hold mode, clusters
+// whose statefulset would change are not synced until the operator restarts in another mode.
+func (c *Controller) runUpgradeDryRun(list *acidv1.PostgresqlList, clusters []*cluster.Cluster) {
+	if c.opConfig.UpgradeDryRun == "off" || c.config.OperatorVersion == "" {
+		return
+	}
+
+	previousVersions := previousOperatorVersions(list, c.config.OperatorVersion)
+	if len(previousVersions) == 0 {
+		c.logger.Debugf("operator version %q did not change, skipping upgrade dry run", c.config.OperatorVersion)
+		return
+	}
+
+	c.logger.Infof("operator version changed from %s to %q, generating statefulsets of %d clusters in dry run mode",
+		strings.Join(previousVersions, ", "), c.config.OperatorVersion, len(clusters))
+	startTime := time.Now()
+	report := newUpgradeDryRun(c.dryRunStatefulSets(clusters))
+	report.PreviousVersions = previousVersions
+	report.OperatorVersion = c.config.OperatorVersion
+	report.StartTime = startTime
+	report.EndTime = time.Now()
+	c.logUpgradeDryRun(report)
+
+	held := make(map[spec.NamespacedName]bool)
+	if c.opConfig.UpgradeDryRun == "hold" {
+		for _, result := range report.Results {
+			if result.Changed {
+				held[result.Cluster] = true
+			}
+		}
+		if len(held) > 0 {
+			c.logger.Warningf("holding %d clusters whose statefulset would change, restart the operator with upgrade_dry_run set to \"report\" to proceed", len(held))
+		}
+	}
+
+	c.upgradeDryRunMu.Lock()
+	c.upgradeDryRun = report
+	c.upgradeDryRunHeld = held
+	c.upgradeDryRunMu.Unlock()
+}
+
+func (c *Controller) upgradeDryRunHolds(clusterName spec.NamespacedName) bool {
+	c.upgradeDryRunMu.RLock()
+	defer c.upgradeDryRunMu.RUnlock()
+
+	return c.upgradeDryRunHeld[clusterName]
+}
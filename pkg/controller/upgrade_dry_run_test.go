@@ -0,0 +1,149 @@
+package controller
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	acidv1 "github.com/zalando/postgres-operator/pkg/apis/acid.zalan.do/v1"
+	"github.com/zalando/postgres-operator/pkg/cluster"
+	"github.com/zalando/postgres-operator/pkg/spec"
+	"github.com/zalando/postgres-operator/pkg/util/config"
+	"github.com/zalando/postgres-operator/pkg/util/k8sutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestPreviousOperatorVersions(t *testing.T) {
+	list := &acidv1.PostgresqlList{}
+	for _, version := range []string{"v1.13.0", "", "v1.14.0", "v1.12.2", "v1.13.0"} {
+		pg := acidv1.Postgresql{}
+		if version != "" {
+			pg.Annotations = map[string]string{cluster.ManagedByVersionAnnotation: version}
+		}
+		list.Items = append(list.Items, pg)
+	}
+
+	versions := previousOperatorVersions(list, "v1.14.0")
+	if !reflect.DeepEqual(versions, []string{"v1.12.2", "v1.13.0"}) {
+		t.Errorf("expected previous versions v1.12.2 and v1.13.0, got %v", versions)
+	}
+	if versions = previousOperatorVersions(&acidv1.PostgresqlList{}, "v1.14.0"); len(versions) != 0 {
+		t.Errorf("expected no previous versions, got %v", versions)
+	}
+}
+
+func TestNewUpgradeDryRun(t *testing.T) {
+	report := newUpgradeDryRun([]spec.StatefulSetDryRun{
+		{
+			Cluster: spec.NamespacedName{Namespace: "default", Name: "unchanged"},
+		},
+		{
+			Cluster:       spec.NamespacedName{Namespace: "default", Name: "rolling-update"},
+			Changed:       true,
+			RollingUpdate: true,
+			Reasons: []string{
+				"new statefulset's annotations do not match: Added \"foo\" with value \"bar\".",
+				"new statefulset's annotations do not match: Added \"baz\" with value \"qux\".",
+				"new statefulset's container \"postgres\" (index 0) image does not match the current one",
+			},
+		},
+		{
+			Cluster: spec.NamespacedName{Namespace: "default", Name: "replace"},
+			Changed: true,
+			Replace: true,
+			Reasons: []string{"new statefulset's annotations do not match: Removed \"foo\"."},
+		},
+		{
+			Cluster: spec.NamespacedName{Namespace: "default", Name: "failed"},
+			Error:   "could not generate statefulset",
+		},
+	})
+
+	if report.Clusters != 4 || report.Changed != 2 || report.Replace != 1 || report.RollingUpdate != 1 || report.Failed != 1 {
+		t.Errorf("unexpected upgrade dry run counts: %+v", report)
+	}
+	expectedReasons := map[string]int{
+		"new statefulset's annotations do not match":                                              2,
+		"new statefulset's container \"postgres\" (index 0) image does not match the current one": 1,
+	}
+	if !reflect.DeepEqual(report.Reasons, expectedReasons) {
+		t.Errorf("expected reasons %v, got %v", expectedReasons, report.Reasons)
+	}
+}
+
+func TestRunUpgradeDryRun(t *testing.T) {
+	clientSet := fake.NewSimpleClientset()
+	client := k8sutil.KubernetesClient{
+		StatefulSetsGetter: clientSet.AppsV1(),
+	}
+
+	pg := acidv1.Postgresql{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "acid-test-cluster",
+			Namespace:   "default",
+			Annotations: map[string]string{cluster.ManagedByVersionAnnotation: "v1.13.0"},
+		},
+	}
+	list := &acidv1.PostgresqlList{Items: []acidv1.Postgresql{pg}}
+	clusterName := spec.NamespacedName{Namespace: "default", Name: "acid-test-cluster"}
+
+	tests := []struct {
+		mode    string
+		version string
+		report  bool
+		hold    bool
+	}{
+		{"off", "v1.14.0", false, false},
+		{"report", "v1.13.0", false, false},
+		{"report", "v1.14.0", true, false},
+		{"hold", "v1.14.0", true, true},
+	}
+	for _, tt := range tests {
+		controller := newPostgresqlTestController()
+		controller.opConfig.UpgradeDryRun = tt.mode
+		controller.config.OperatorVersion = tt.version
+		cl := cluster.New(cluster.Config{OpConfig: config.Config{}}, client, pg, controller.logger, controller.eventRecorder)
+
+		controller.runUpgradeDryRun(list, []*cluster.Cluster{cl})
+
+		report, err := controller.UpgradeDryRun()
+		if tt.report {
+			if err != nil {
+				t.Errorf("expected upgrade dry run in mode %q with version %q, got %v", tt.mode, tt.version, err)
+			} else if report.Changed != 1 || !reflect.DeepEqual(report.PreviousVersions, []string{"v1.13.0"}) {
+				t.Errorf("expected missing statefulset to be reported as change, got %+v", report)
+			}
+		} else if err == nil {
+			t.Errorf("expected no upgrade dry run in mode %q with version %q", tt.mode, tt.version)
+		}
+		if hold := controller.upgradeDryRunHolds(clusterName); hold != tt.hold {
+			t.Errorf("expected cluster to be held %t in mode %q, got %t", tt.hold, tt.mode, hold)
+		}
+	}
+}
+
+func TestProcessEventReportsHeldClusters(t *testing.T) {
+	recorder := record.NewFakeRecorder(5)
+	controller := newPostgresqlTestController()
+	controller.eventRecorder = recorder
+	controller.upgradeDryRunHeld = map[spec.NamespacedName]bool{
+		{Namespace: "default", Name: "acid-test-cluster"}: true,
+	}
+
+	pg := &acidv1.Postgresql{
+		TypeMeta:   metav1.TypeMeta{Kind: "postgresql", APIVersion: "acid.zalan.do/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "acid-test-cluster", Namespace: "default"},
+	}
+	controller.processEvent(ClusterEvent{EventType: EventSync, NewSpec: pg})
+
+	select {
+	case event := <-recorder.Events:
+		if !strings.Contains(event, "UpgradeDryRunHold") {
+			t.Errorf("expected UpgradeDryRunHold event for skipped sync, got %q", event)
+		}
+	default:
+		t.Errorf("expected an event for the skipped sync of a held cluster")
+	}
+}
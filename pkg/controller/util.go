@@ -33,6 +33,8 @@ func (c *Controller) makeClusterConfig() cluster.Config {
 		PgTeamMap:           &c.pgTeamMap,
 		InfrastructureRoles: infrastructureRoles,
 		PodServiceAccount:   c.PodServiceAccount,
+		OperatorVersion:     c.config.OperatorVersion,
+		OperatorIdentity:    c.operatorIdentity,
 	}
 }
 
@@ -102,6 +102,31 @@ type ControllerStatus struct {
 	WorkerQueueSize map[int]int
 }
 
+// StatefulSetDryRun describes how the statefulset of a cluster would change when synced
+type StatefulSetDryRun struct {
+	Cluster       NamespacedName
+	Changed       bool
+	Replace       bool
+	RollingUpdate bool
+	Reasons       []string `json:",omitempty"`
+	Error         string   `json:",omitempty"`
+}
+
+// UpgradeDryRun summarizes the statefulset dry run of all clusters after an operator upgrade
+type UpgradeDryRun struct {
+	PreviousVersions []string
+	OperatorVersion  string
+	StartTime        time.Time
+	EndTime          time.Time
+	Clusters         int
+	Changed          int
+	Replace          int
+	RollingUpdate    int
+	Failed           int
+	Reasons          map[string]int
+	Results          []StatefulSetDryRun
+}
+
 // QueueDump describes cache.FIFO queue
 type QueueDump struct {
 	Keys []string
@@ -122,6 +147,7 @@ type ControllerConfig struct {
 	IgnoredAnnotations   []string
 
 	EnableJsonLogging bool
+	OperatorVersion   string
 
 	KubeQPS   int
 	KubeBurst int
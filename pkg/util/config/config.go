@@ -195,6 +195,9 @@ type Config struct {
 	AdditionalSecretMountPath                string            `name:"additional_secret_mount_path"`
 	EnableEBSGp3Migration                    bool              `name:"enable_ebs_gp3_migration" default:"false"`
 	EnableEBSGp3MigrationMaxSize             int64             `name:"enable_ebs_gp3_migration_max_size" default:"1000"`
+	EnableCrashArtifactCollection            bool              `name:"enable_crash_artifact_collection" default:"false"`
+	CrashArtifactExitCodes                   []int32           `name:"crash_artifact_exit_codes" default:"134,135,136,139"`
+	CrashArtifactCoreDumps                   bool              `name:"crash_artifact_core_dumps" default:"false"`
 	DebugLogging                             bool              `name:"debug_logging" default:"true"`
 	EnableDBAccess                           bool              `name:"enable_database_access" default:"true"`
 	EnableTeamsAPI                           bool              `name:"enable_teams_api" default:"true"`
@@ -227,6 +230,20 @@ type Config struct {
 	EnableInitContainers                     *bool             `name:"enable_init_containers" default:"true"`
 	EnableSidecars                           *bool             `name:"enable_sidecars" default:"true"`
 	SharePgSocketWithSidecars                *bool             `name:"share_pgsocket_with_sidecars" default:"false"`
+	EnableDebugContainers                    bool              `name:"enable_debug_containers" default:"false"`
+	DebugContainerImage                      string            `name:"debug_container_image" default:""`
+	DebugContainerPrivileged                 bool              `name:"debug_container_privileged" default:"false"`
+	DebugContainerTTL                        time.Duration     `name:"debug_container_ttl" default:"1h"`
+	EnablePrometheusRules                    bool              `name:"enable_prometheus_rules" default:"false"`
+	PrometheusRuleLabels                     map[string]string `name:"prometheus_rule_labels" default:""`
+	EnableEffectiveSpecAnnotation            bool              `name:"enable_effective_spec_annotation" default:"false"`
+	EnableGenerationAnnotations              bool              `name:"enable_generation_annotations" default:"false"`
+	EnableCheckJobs                          bool              `name:"enable_check_jobs" default:"false"`
+	CheckJobPrefix                           string            `name:"check_job_prefix" default:"check-"`
+	CheckJobDockerImage                      StringTemplate    `name:"check_job_docker_image" default:""`
+	ManagedByFencing                         string            `name:"managed_by_fencing" default:"off"`
+	ManagedByLeaseDuration                   time.Duration     `name:"managed_by_lease_duration" default:"2h"`
+	UpgradeDryRun                            string            `name:"upgrade_dry_run" default:"off"`
 	Workers                                  uint32            `name:"workers" default:"8"`
 	APIPort                                  int               `name:"api_port" default:"8080"`
 	RingLogLines                             int               `name:"ring_log_lines" default:"100"`
@@ -324,5 +341,9 @@ func validate(cfg *Config) (err error) {
 		err = fmt.Errorf(msg, cfg.ConnectionPooler.User)
 	}
 
+	if cfg.EnableDebugContainers && cfg.DebugContainerImage == "" {
+		err = fmt.Errorf("debug_container_image has to be set when debug containers are enabled")
+	}
+
 	return
 }